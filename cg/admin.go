@@ -0,0 +1,178 @@
+package cg
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *Server) adminRoutes(r chi.Router) {
+	r.Use(s.requireAdmin)
+	r.Get("/metrics", s.metricsEndpoint)
+	r.Get("/games", s.adminGamesEndpoint)
+	r.Delete("/games/{gameId}", s.adminCloseGameEndpoint)
+	r.Post("/games/{gameId}/events", s.adminInjectEndpoint)
+	r.Delete("/games/{gameId}/players/{playerId}", s.adminKickPlayerEndpoint)
+	r.Delete("/players/{playerId}", s.adminForgetPlayerEndpoint)
+}
+
+func (s *Server) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.config.AdminToken == "" {
+			send(w, http.StatusNotFound, "admin API disabled")
+			return
+		}
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.config.AdminToken)) != 1 {
+			send(w, http.StatusUnauthorized, "invalid admin token")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) adminGamesEndpoint(w http.ResponseWriter, r *http.Request) {
+	type game struct {
+		ID         string            `json:"id"`
+		Public     bool              `json:"public"`
+		Protected  bool              `json:"protected"`
+		Players    map[string]string `json:"players"`
+		Spectators int               `json:"spectators"`
+	}
+
+	s.gamesLock.RLock()
+	games := make([]game, 0, len(s.games))
+	for _, g := range s.games {
+		g.spectatorsLock.RLock()
+		spectators := len(g.spectators)
+		g.spectatorsLock.RUnlock()
+
+		games = append(games, game{
+			ID:         g.ID,
+			Public:     g.public,
+			Protected:  g.joinSecret != "",
+			Players:    g.playerUsernameMap(),
+			Spectators: spectators,
+		})
+	}
+	s.gamesLock.RUnlock()
+
+	sendJSON(w, http.StatusOK, games)
+}
+
+func (s *Server) adminCloseGameEndpoint(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameId")
+
+	game, ok := s.getGame(gameID)
+	if !ok {
+		s.sendGameNotFound(w, gameID)
+		return
+	}
+
+	s.log.Warning("Closing game %s by admin request.", game.ID)
+	game.CloseWithReason("closed by an admin")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) adminKickPlayerEndpoint(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameId")
+	playerID := chi.URLParam(r, "playerId")
+
+	game, ok := s.getGame(gameID)
+	if !ok {
+		s.sendGameNotFound(w, gameID)
+		return
+	}
+
+	player, ok := game.GetPlayer(playerID)
+	if !ok {
+		send(w, http.StatusNotFound, "player not found")
+		return
+	}
+
+	game.Log.Warning("Kicking player '%s' (%s) by admin request.", player.username(), player.ID)
+	game.leave(player)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// adminForgetPlayerEndpoint purges all data of a player, e.g. of a player who has already left the game
+// and can no longer authenticate with the player secret.
+func (s *Server) adminForgetPlayerEndpoint(w http.ResponseWriter, r *http.Request) {
+	if !s.ForgetPlayer(chi.URLParam(r, "playerId")) {
+		send(w, http.StatusNotFound, "player not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// adminInjectEndpoint broadcasts an event into a game or adds a command to its command queue,
+// e.g. to void a round, make announcements or debug stuck games.
+func (s *Server) adminInjectEndpoint(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameId")
+
+	type request struct {
+		// "event" or "command"
+		Type string          `json:"type"`
+		Name string          `json:"name"`
+		Data json.RawMessage `json:"data"`
+		// The player the event is sent to or the command originates from. (empty => all players or no origin)
+		PlayerID string `json:"player_id"`
+	}
+	var req request
+	err := DecodeJSONBody(w, r, &req, DefaultMaxBodySize)
+	if err != nil {
+		return
+	}
+	if req.Name == "" {
+		send(w, http.StatusBadRequest, "missing name")
+		return
+	}
+
+	game, ok := s.getGame(gameID)
+	if !ok {
+		s.sendGameNotFound(w, gameID)
+		return
+	}
+
+	var player *Player
+	if req.PlayerID != "" {
+		player, ok = game.GetPlayer(req.PlayerID)
+		if !ok {
+			send(w, http.StatusNotFound, "player not found")
+			return
+		}
+	}
+
+	switch req.Type {
+	case "event":
+		game.Log.WarningData(req.Data, "Injecting '%s' event by admin request.", req.Name)
+		if player != nil {
+			err = player.Send(EventName(req.Name), req.Data)
+		} else {
+			err = game.Send(EventName(req.Name), req.Data)
+		}
+	case "command":
+		game.Log.WarningData(req.Data, "Injecting '%s' command by admin request.", req.Name)
+		err = game.InjectCommand(player, Command{
+			Name: CommandName(req.Name),
+			Data: req.Data,
+		})
+	default:
+		send(w, http.StatusBadRequest, "type must be 'event' or 'command'")
+		return
+	}
+	if err != nil {
+		send(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,75 @@
+package cg
+
+import (
+	"time"
+)
+
+// EventServerAnnouncement is sent to every connected socket of the server by Server.Announce.
+const EventServerAnnouncement EventName = "cg_server_announcement"
+
+type ServerAnnouncementEventData struct {
+	Level   NotificationLevel `json:"level"`
+	Message string            `json:"message"`
+}
+
+type maintenanceInfo struct {
+	// The time at which the maintenance starts in unix milliseconds.
+	Start   int64  `json:"start"`
+	Message string `json:"message,omitempty"`
+}
+
+// Announce sends a cg_server_announcement event to all players, spectators and room members of the server,
+// e.g. to warn about an upcoming restart.
+func (s *Server) Announce(message string, level NotificationLevel) {
+	data := ServerAnnouncementEventData{
+		Level:   level,
+		Message: message,
+	}
+
+	s.gamesLock.RLock()
+	games := make([]*Game, 0, len(s.games))
+	for _, g := range s.games {
+		games = append(games, g)
+	}
+	s.gamesLock.RUnlock()
+
+	for _, g := range games {
+		g.SendServerAnnouncement(level, message)
+	}
+
+	s.roomsLock.RLock()
+	rooms := make([]*Room, 0, len(s.rooms))
+	for _, r := range s.rooms {
+		rooms = append(rooms, r)
+	}
+	s.roomsLock.RUnlock()
+
+	for _, r := range rooms {
+		r.broadcast(EventServerAnnouncement, data)
+	}
+
+	s.log.Info("Announced: %s", message)
+}
+
+// ScheduleMaintenance publishes the start of a planned maintenance in /api/info.
+func (s *Server) ScheduleMaintenance(start time.Time, message string) {
+	s.maintenanceLock.Lock()
+	s.maintenance = &maintenanceInfo{
+		Start:   start.UnixMilli(),
+		Message: message,
+	}
+	s.maintenanceLock.Unlock()
+}
+
+// CancelMaintenance removes the maintenance published with ScheduleMaintenance.
+func (s *Server) CancelMaintenance() {
+	s.maintenanceLock.Lock()
+	s.maintenance = nil
+	s.maintenanceLock.Unlock()
+}
+
+func (s *Server) scheduledMaintenance() *maintenanceInfo {
+	s.maintenanceLock.RLock()
+	defer s.maintenanceLock.RUnlock()
+	return s.maintenance
+}
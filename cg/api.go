@@ -2,9 +2,13 @@ package cg
 
 import (
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/Bananenpro/log"
 	"github.com/go-chi/chi/v5"
@@ -13,38 +17,64 @@ import (
 
 func (s *Server) apiRoutes(r chi.Router) {
 	r.Get("/info", s.infoEndpoint)
+	r.Get("/info/build", s.buildInfoEndpoint)
+	r.Get("/ping", s.pingEndpoint)
 	r.Get("/events", s.eventsEndpoint)
+	r.Get("/events/html", s.eventsHTMLEndpoint)
 	r.Get("/logo", s.logoEndpoint)
+	r.Get("/presets", s.presetsEndpoint)
 	r.Get("/games", s.gamesEndpoint)
 	r.Post("/games", s.createGameEndpoint)
+	r.Post("/games/join-any", s.joinAnyEndpoint)
+	r.Get("/players/sessions", s.sessionsEndpoint)
 	r.Get("/games/{gameId}", s.gameEndpoint)
 	r.Get("/games/{gameId}/players", s.playersEndpoint)
+	r.Get("/games/{gameId}/results", s.resultsEndpoint)
 	r.Post("/games/{gameId}/players", s.createPlayerEndpoint)
 	r.Get("/games/{gameId}/players/{playerId}", s.playerEndpoint)
+	r.Delete("/games/{gameId}/players/{playerId}", s.forgetPlayerEndpoint)
 	r.Get("/games/{gameId}/players/{playerId}/connect", s.connectEndpoint)
+	r.Get("/games/{gameId}/players/{playerId}/sockets", s.playerSocketsEndpoint)
 	r.Get("/games/{gameId}/spectate", s.spectateEndpoint)
+	r.Get("/spectate", s.multiSpectateEndpoint)
+
+	r.Route("/rooms", s.roomRoutes)
+	r.Route("/game", s.gameRoutes)
+	r.Route("/admin", s.adminRoutes)
 
 	r.Get("/debug", s.debugServer)
 	r.Get("/games/{gameId}/debug", s.debugGame)
 	r.Get("/games/{gameId}/players/{playerId}/debug", s.debugPlayer)
+	r.Get("/games/{gameId}/players/{playerId}/debug/journal", s.debugJournalEndpoint)
 }
 
 func (s *Server) infoEndpoint(w http.ResponseWriter, r *http.Request) {
 	type response struct {
-		Name          string `json:"name"`
-		CGVersion     string `json:"cg_version"`
-		DisplayName   string `json:"display_name,omitempty"`
-		Description   string `json:"description,omitempty"`
-		Version       string `json:"version,omitempty"`
-		RepositoryURL string `json:"repository_url,omitempty"`
-	}
+		Name          string           `json:"name"`
+		CGVersion     string           `json:"cg_version"`
+		MinCGVersion  string           `json:"min_cg_version"`
+		MaxCGVersion  string           `json:"max_cg_version"`
+		DisplayName   string           `json:"display_name,omitempty"`
+		Description   string           `json:"description,omitempty"`
+		Version       string           `json:"version,omitempty"`
+		RepositoryURL string           `json:"repository_url,omitempty"`
+		Locales       []string         `json:"locales,omitempty"`
+		Maintenance   *maintenanceInfo `json:"maintenance,omitempty"`
+		Region        string           `json:"region,omitempty"`
+	}
+	displayName, description := s.localizedInfo(r.URL.Query().Get("lang"))
 	sendJSON(w, http.StatusOK, response{
 		Name:          s.config.Name,
 		CGVersion:     CGVersion,
-		DisplayName:   s.config.DisplayName,
-		Description:   s.config.Description,
+		MinCGVersion:  MinClientCGVersion,
+		MaxCGVersion:  MaxClientCGVersion,
+		DisplayName:   displayName,
+		Description:   description,
 		Version:       s.config.Version,
 		RepositoryURL: s.config.RepositoryURL,
+		Locales:       s.localeNames(),
+		Maintenance:   s.scheduledMaintenance(),
+		Region:        s.config.Region,
 	})
 }
 
@@ -82,21 +112,27 @@ func (s *Server) gamesEndpoint(w http.ResponseWriter, r *http.Request) {
 		ID        string `json:"id"`
 		Players   int    `json:"players"`
 		Protected bool   `json:"protected"`
+		Region    string `json:"region,omitempty"`
+		// The name of the autostart game, see ServerConfig.AutostartGames.
+		Autostart string `json:"autostart,omitempty"`
 	}
 
 	protectedParam := r.URL.Query().Get("protected")
 	protected, _ := strconv.ParseBool(protectedParam)
+	region := r.URL.Query().Get("region")
 
 	s.gamesLock.RLock()
 	publicGames := make([]game, 0, len(s.games)/2)
 	private := 0
 	for _, g := range s.games {
-		if protectedParam == "" || protected == (g.joinSecret != "") {
+		if (protectedParam == "" || protected == (g.joinSecret != "")) && (region == "" || region == g.Region()) {
 			if g.public {
 				publicGames = append(publicGames, game{
 					ID:        g.ID,
 					Players:   len(g.players),
 					Protected: g.joinSecret != "",
+					Region:    g.Region(),
+					Autostart: g.autostart.Name,
 				})
 			} else {
 				private++
@@ -116,28 +152,41 @@ func (s *Server) gamesEndpoint(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) createGameEndpoint(w http.ResponseWriter, r *http.Request) {
-	body := r.Body
-	if body == nil {
-		send(w, http.StatusBadRequest, "empty request body")
-		return
-	}
-	defer body.Close()
-
 	type request struct {
 		Public    bool            `json:"public"`
 		Protected bool            `json:"protected"`
 		Config    json.RawMessage `json:"config"`
+		// The name of a registered preset which is used instead of config.
+		Preset string `json:"preset"`
 	}
 	var req request
-	err := json.NewDecoder(body).Decode(&req)
+	err := DecodeJSONBody(w, r, &req, DefaultMaxBodySize)
 	if err != nil {
-		send(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
-	gameID, joinSecret, err := s.createGame(req.Public, req.Protected, req.Config)
-	if err != nil {
-		send(w, http.StatusForbidden, "max game count reached")
+	if req.Preset != "" {
+		if len(req.Config) > 0 && string(req.Config) != "null" {
+			send(w, http.StatusBadRequest, "preset and config are mutually exclusive")
+			return
+		}
+		config, ok := s.getPreset(req.Preset)
+		if !ok {
+			send(w, http.StatusNotFound, "preset not found")
+			return
+		}
+		req.Config = config
+	}
+
+	gameID, joinSecret, err := s.createGame(req.Public, req.Protected, s.isTrialRequest(r), req.Config)
+	if errors.Is(err, ErrMaxGamesReached) {
+		send(w, http.StatusForbidden, err.Error())
+		return
+	} else if errors.Is(err, ErrServerClosed) {
+		send(w, http.StatusServiceUnavailable, err.Error())
+		return
+	} else if err != nil {
+		send(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -151,28 +200,131 @@ func (s *Server) createGameEndpoint(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (s *Server) joinAnyEndpoint(w http.ResponseWriter, r *http.Request) {
+	type request struct {
+		Username string          `json:"username"`
+		Lang     string          `json:"lang"`
+		Config   json.RawMessage `json:"config"`
+		// A random secret of at least 32 characters generated by the client to find the game again with /api/players/sessions.
+		ClientSecret string `json:"client_secret"`
+	}
+	var req request
+	err := DecodeJSONBody(w, r, &req, DefaultMaxBodySize)
+	if err != nil {
+		return
+	}
+	if req.Username == "" {
+		send(w, http.StatusBadRequest, "missing username")
+		return
+	}
+	clientSecret, err := hashClientSecret(req.ClientSecret)
+	if err != nil {
+		send(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	created := false
+	var playerID, playerSecret string
+	game, ok := s.findOpenGame()
+	if ok {
+		playerID, playerSecret, err = game.join(req.Username, "", remoteHost(r), req.Lang, clientSecret)
+	}
+	if !ok || err != nil {
+		gameID, _, err := s.createGame(true, false, s.isTrialRequest(r), req.Config)
+		if errors.Is(err, ErrMaxGamesReached) {
+			send(w, http.StatusForbidden, err.Error())
+			return
+		} else if errors.Is(err, ErrServerClosed) {
+			send(w, http.StatusServiceUnavailable, err.Error())
+			return
+		} else if err != nil {
+			send(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		game, ok = s.getGame(gameID)
+		if !ok {
+			send(w, http.StatusInternalServerError, "game closed immediately")
+			return
+		}
+		created = true
+		playerID, playerSecret, err = game.join(req.Username, "", remoteHost(r), req.Lang, clientSecret)
+		if err != nil {
+			send(w, http.StatusForbidden, err.Error())
+			return
+		}
+	}
+
+	type response struct {
+		GameID       string `json:"game_id"`
+		Created      bool   `json:"created"`
+		PlayerID     string `json:"player_id"`
+		PlayerSecret string `json:"player_secret"`
+	}
+	sendJSON(w, http.StatusCreated, response{
+		GameID:       game.ID,
+		Created:      created,
+		PlayerID:     playerID,
+		PlayerSecret: playerSecret,
+	})
+}
+
 func (s *Server) gameEndpoint(w http.ResponseWriter, r *http.Request) {
 	gameID := chi.URLParam(r, "gameId")
 
 	game, ok := s.getGame(gameID)
 	if !ok {
-		send(w, http.StatusNotFound, "game not found")
+		s.sendGameNotFound(w, gameID)
 		return
 	}
 
+	type stats struct {
+		// The time since the game was created in seconds.
+		Uptime            int64 `json:"uptime"`
+		EventsBroadcast   int64 `json:"events_broadcast"`
+		CommandsProcessed int64 `json:"commands_processed"`
+		Spectators        int   `json:"spectators"`
+	}
 	type response struct {
 		ID        string `json:"id"`
 		Players   int    `json:"players"`
 		Protected bool   `json:"protected"`
 		Config    any    `json:"config,omitempty"`
+		Host      string `json:"host,omitempty"`
+		Trial     bool   `json:"trial,omitempty"`
+		Region    string `json:"region,omitempty"`
+		// The optional mechanics enabled in the game, see Game.SetFeatures.
+		Features map[string]bool `json:"features,omitempty"`
+		*stats
 	}
 
-	sendJSON(w, http.StatusOK, response{
+	visibility := game.Visibility()
+	res := response{
 		ID:        game.ID,
 		Players:   len(game.players),
 		Protected: game.joinSecret != "",
-		Config:    game.config,
-	})
+		Trial:     game.trial,
+		Region:    game.Region(),
+		Features:  game.Features(),
+	}
+
+	if !visibility.HideConfig {
+		res.Config = game.Config()
+	}
+
+	if h := game.Host(); h != nil && !visibility.HidePlayers {
+		res.Host = h.ID
+	}
+
+	if !visibility.HideStats {
+		res.stats = &stats{
+			Uptime:            int64(game.Uptime().Seconds()),
+			EventsBroadcast:   game.EventsBroadcast(),
+			CommandsProcessed: game.CommandsProcessed(),
+			Spectators:        game.SpectatorCount(),
+		}
+	}
+
+	sendJSON(w, http.StatusOK, res)
 }
 
 func (s *Server) playersEndpoint(w http.ResponseWriter, r *http.Request) {
@@ -180,7 +332,11 @@ func (s *Server) playersEndpoint(w http.ResponseWriter, r *http.Request) {
 
 	game, ok := s.getGame(gameID)
 	if !ok {
-		send(w, http.StatusNotFound, "game not found")
+		s.sendGameNotFound(w, gameID)
+		return
+	}
+
+	if !s.authorizePlayerInfo(w, r, game) {
 		return
 	}
 
@@ -189,33 +345,53 @@ func (s *Server) playersEndpoint(w http.ResponseWriter, r *http.Request) {
 	sendJSON(w, http.StatusOK, players)
 }
 
+// authorizePlayerInfo writes an error response and returns false if the request may not read the usernames
+// of the players of the game because of HidePlayers or ProtectPlayerList.
+func (s *Server) authorizePlayerInfo(w http.ResponseWriter, r *http.Request, game *Game) bool {
+	if game.Visibility().HidePlayers {
+		send(w, http.StatusForbidden, "the players of this game are hidden")
+		return false
+	}
+
+	if s.config.ProtectPlayerList && !game.authorizePlayerList(r.URL.Query().Get("player_secret"), r.URL.Query().Get("join_secret")) {
+		send(w, http.StatusUnauthorized, "missing or wrong `player_secret` or `join_secret` query parameter")
+		return false
+	}
+	return true
+}
+
 func (s *Server) createPlayerEndpoint(w http.ResponseWriter, r *http.Request) {
 	gameID := chi.URLParam(r, "gameId")
 
-	body := r.Body
-	if body == nil {
-		send(w, http.StatusBadRequest, "empty request body")
-		return
-	}
-	defer body.Close()
 	type request struct {
 		Username   string `json:"username"`
 		JoinSecret string `json:"join_secret"`
+		Lang       string `json:"lang"`
+		// A random secret of at least 32 characters generated by the client to find the game again with /api/players/sessions.
+		ClientSecret string `json:"client_secret"`
 	}
 	var req request
-	err := json.NewDecoder(body).Decode(&req)
-	if err != nil || req.Username == "" {
-		send(w, http.StatusBadRequest, "invalid request body")
+	err := DecodeJSONBody(w, r, &req, DefaultMaxBodySize)
+	if err != nil {
+		return
+	}
+	if req.Username == "" {
+		send(w, http.StatusBadRequest, "missing username")
+		return
+	}
+	clientSecret, err := hashClientSecret(req.ClientSecret)
+	if err != nil {
+		send(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	game, ok := s.getGame(gameID)
 	if !ok {
-		send(w, http.StatusNotFound, "game not found")
+		s.sendGameNotFound(w, gameID)
 		return
 	}
 
-	playerID, playerSecret, err := game.join(req.Username, req.JoinSecret)
+	playerID, playerSecret, err := game.join(req.Username, req.JoinSecret, remoteHost(r), req.Lang, clientSecret)
 	if err != nil {
 		send(w, http.StatusForbidden, err.Error())
 		return
@@ -237,7 +413,11 @@ func (s *Server) playerEndpoint(w http.ResponseWriter, r *http.Request) {
 
 	game, ok := s.getGame(gameID)
 	if !ok {
-		send(w, http.StatusNotFound, "game not found")
+		s.sendGameNotFound(w, gameID)
+		return
+	}
+
+	if !s.authorizePlayerInfo(w, r, game) {
 		return
 	}
 
@@ -248,11 +428,56 @@ func (s *Server) playerEndpoint(w http.ResponseWriter, r *http.Request) {
 	}
 
 	type response struct {
-		Username string `json:"username"`
+		Username   string `json:"username"`
+		Color      string `json:"color"`
+		ColorIndex int    `json:"color_index"`
 	}
 	sendJSON(w, http.StatusOK, response{
-		Username: player.Username,
+		Username:   player.username(),
+		Color:      player.Color(),
+		ColorIndex: player.ColorIndex(),
+	})
+}
+
+// playerSocketsEndpoint lists the sockets connected to the player, e.g. to debug duplicate connections.
+func (s *Server) playerSocketsEndpoint(w http.ResponseWriter, r *http.Request) {
+	player, ok := s.RequestPlayer(w, r)
+	if !ok {
+		return
+	}
+
+	type socket struct {
+		socketInfo
+		BytesSent     int64 `json:"bytes_sent"`
+		BytesReceived int64 `json:"bytes_received"`
+	}
+
+	player.socketsLock.RLock()
+	sockets := make([]socket, 0, len(player.sockets))
+	for _, s := range player.sockets {
+		sockets = append(sockets, socket{
+			socketInfo:    s.info(),
+			BytesSent:     s.BytesSent(),
+			BytesReceived: s.BytesReceived(),
+		})
+	}
+	player.socketsLock.RUnlock()
+
+	sort.Slice(sockets, func(i, j int) bool {
+		return sockets[i].ConnectedAt.Before(sockets[j].ConnectedAt)
 	})
+	sendJSON(w, http.StatusOK, sockets)
+}
+
+func (s *Server) forgetPlayerEndpoint(w http.ResponseWriter, r *http.Request) {
+	player, ok := s.RequestPlayer(w, r)
+	if !ok {
+		return
+	}
+
+	s.ForgetPlayer(player.ID)
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func (s *Server) connectEndpoint(w http.ResponseWriter, r *http.Request) {
@@ -266,7 +491,7 @@ func (s *Server) connectEndpoint(w http.ResponseWriter, r *http.Request) {
 
 	game, ok := s.getGame(gameID)
 	if !ok {
-		send(w, http.StatusNotFound, "game not found")
+		s.sendGameNotFound(w, gameID)
 		return
 	}
 
@@ -276,8 +501,11 @@ func (s *Server) connectEndpoint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if player.Secret != playerSecret {
-		send(w, http.StatusForbidden, "wrong player secret")
+	if !s.checkPlayerSecret(w, player, playerSecret) {
+		return
+	}
+
+	if !checkClientVersion(w, r) {
 		return
 	}
 
@@ -286,11 +514,7 @@ func (s *Server) connectEndpoint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	socket := &GameSocket{
-		ID:     uuid.NewString(),
-		server: s,
-		conn:   conn,
-	}
+	socket := s.newGameSocket(conn, r.RemoteAddr, r.UserAgent())
 
 	err = player.addSocket(socket)
 	if err != nil {
@@ -298,7 +522,7 @@ func (s *Server) connectEndpoint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	player.Log.Trace("New socket connected with id %s.", socket.ID)
+	player.Log.TraceData(socket.info(), "New socket connected with id %s.", socket.ID)
 
 	go socket.handleConnection()
 
@@ -312,27 +536,35 @@ func (s *Server) spectateEndpoint(w http.ResponseWriter, r *http.Request) {
 
 	game, ok := s.getGame(gameID)
 	if !ok {
-		send(w, http.StatusNotFound, "game not found")
+		s.sendGameNotFound(w, gameID)
 		return
 	}
 
-	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if !checkClientVersion(w, r) {
+		return
+	}
+
+	tier, err := parseSpectatorTier(r.URL.Query().Get("tier"))
 	if err != nil {
+		send(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	socket := &GameSocket{
-		ID:     uuid.NewString(),
-		server: s,
-		conn:   conn,
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
 	}
 
+	socket := s.newGameSocket(conn, r.RemoteAddr, r.UserAgent())
+	socket.tier = tier
+	socket.useSendQueue()
+
 	err = game.addSpectator(socket)
 	if err != nil {
 		send(w, http.StatusForbidden, err.Error())
 	}
 
-	game.Log.Trace("New spectator socket connected with id %s.", socket.ID)
+	game.Log.TraceData(socket.info(), "New spectator socket connected with id %s.", socket.ID)
 
 	go socket.handleConnection()
 }
@@ -348,7 +580,9 @@ func (s *Server) debugServer(w http.ResponseWriter, r *http.Request) {
 		server:     s,
 		logger:     s.log,
 		conn:       conn,
+		done:       make(chan struct{}),
 		severities: getDebugSeverities(r),
+		names:      getDebugNames(r),
 	}
 
 	socket.logger.addDebugSocket(socket)
@@ -360,7 +594,7 @@ func (s *Server) debugGame(w http.ResponseWriter, r *http.Request) {
 	gameID := chi.URLParam(r, "gameId")
 	game, ok := s.getGame(gameID)
 	if !ok {
-		send(w, http.StatusNotFound, "game not found")
+		s.sendGameNotFound(w, gameID)
 		return
 	}
 
@@ -374,7 +608,9 @@ func (s *Server) debugGame(w http.ResponseWriter, r *http.Request) {
 		server:     s,
 		logger:     game.Log,
 		conn:       conn,
+		done:       make(chan struct{}),
 		severities: getDebugSeverities(r),
+		names:      getDebugNames(r),
 	}
 
 	socket.logger.addDebugSocket(socket)
@@ -393,7 +629,7 @@ func (s *Server) debugPlayer(w http.ResponseWriter, r *http.Request) {
 
 	game, ok := s.getGame(gameID)
 	if !ok {
-		send(w, http.StatusNotFound, "game not found")
+		s.sendGameNotFound(w, gameID)
 		return
 	}
 
@@ -403,8 +639,7 @@ func (s *Server) debugPlayer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if player.Secret != playerSecret {
-		send(w, http.StatusForbidden, "wrong player secret")
+	if !s.checkPlayerSecret(w, player, playerSecret) {
 		return
 	}
 
@@ -418,7 +653,9 @@ func (s *Server) debugPlayer(w http.ResponseWriter, r *http.Request) {
 		server:     s,
 		logger:     player.Log,
 		conn:       conn,
+		done:       make(chan struct{}),
 		severities: getDebugSeverities(r),
+		names:      getDebugNames(r),
 	}
 
 	socket.logger.addDebugSocket(socket)
@@ -453,6 +690,23 @@ func getDebugSeverities(r *http.Request) map[DebugSeverity]bool {
 	return severities
 }
 
+// getDebugNames returns the event and command name patterns of the comma separated `names` query parameter, e.g. `move,cg_*`.
+func getDebugNames(r *http.Request) []string {
+	query := r.URL.Query().Get("names")
+	if query == "" {
+		return nil
+	}
+	names := make([]string, 0)
+	for _, n := range strings.Split(query, ",") {
+		n = strings.TrimSpace(n)
+		if n == "" {
+			continue
+		}
+		names = append(names, n)
+	}
+	return names
+}
+
 func sendJSON(w http.ResponseWriter, status int, data any) {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
@@ -464,6 +718,14 @@ func sendJSON(w http.ResponseWriter, status int, data any) {
 	w.Write(jsonData)
 }
 
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 func send(w http.ResponseWriter, status int, msg string) {
 	w.WriteHeader(status)
 	w.Write([]byte(msg))
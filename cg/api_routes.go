@@ -0,0 +1,83 @@
+package cg
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type apiRoute struct {
+	method  string
+	pattern string
+	handler http.HandlerFunc
+}
+
+// RegisterAPIRoute adds a game-specific endpoint under /api/game, e.g. RegisterAPIRoute("GET", "/maps/{mapId}", handler)
+// serves /api/game/maps/{mapId}. Patterns use the chi syntax and URL parameters are available with URLParam.
+// Handlers can use RequestGame and RequestPlayer to look up and authenticate games and players.
+// Routes must be registered before calling Run or Handler.
+func (s *Server) RegisterAPIRoute(method, pattern string, handler http.HandlerFunc) {
+	if !strings.HasPrefix(pattern, "/") {
+		pattern = "/" + pattern
+	}
+	s.apiRoutesLock.Lock()
+	s.customRoutes = append(s.customRoutes, apiRoute{
+		method:  strings.ToUpper(method),
+		pattern: pattern,
+		handler: handler,
+	})
+	s.apiRoutesLock.Unlock()
+}
+
+func (s *Server) gameRoutes(r chi.Router) {
+	s.apiRoutesLock.Lock()
+	defer s.apiRoutesLock.Unlock()
+	for _, route := range s.customRoutes {
+		r.Method(route.method, route.pattern, route.handler)
+	}
+}
+
+// URLParam returns the value of the URL parameter key of a route registered with RegisterAPIRoute.
+func URLParam(r *http.Request, key string) string {
+	return chi.URLParam(r, key)
+}
+
+// RequestGame returns the game with the ID in the {gameId} URL parameter.
+// If there is no such game, an error response is written to w and ok is false.
+func (s *Server) RequestGame(w http.ResponseWriter, r *http.Request) (game *Game, ok bool) {
+	gameID := chi.URLParam(r, "gameId")
+	game, ok = s.getGame(gameID)
+	if !ok {
+		s.sendGameNotFound(w, gameID)
+	}
+	return game, ok
+}
+
+// RequestPlayer returns the player with the ID in the {playerId} URL parameter of the game with the ID in the {gameId} URL parameter
+// if the player_secret query parameter matches the secret of the player.
+// Otherwise an error response is written to w and ok is false.
+func (s *Server) RequestPlayer(w http.ResponseWriter, r *http.Request) (player *Player, ok bool) {
+	playerSecret := r.URL.Query().Get("player_secret")
+	if playerSecret == "" {
+		send(w, http.StatusBadRequest, "missing `player_secret` query parameter")
+		return nil, false
+	}
+
+	game, ok := s.RequestGame(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	player, ok = game.GetPlayer(chi.URLParam(r, "playerId"))
+	if !ok {
+		send(w, http.StatusNotFound, "player not found")
+		return nil, false
+	}
+
+	if !s.checkPlayerSecret(w, player, playerSecret) {
+		return nil, false
+	}
+
+	return player, true
+}
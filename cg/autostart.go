@@ -0,0 +1,98 @@
+package cg
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	// The delay before a closed or failed autostart game is recreated for the first time.
+	autostartDelay = time.Second
+	// The maximum delay between attempts to recreate an autostart game. The delay is doubled for every
+	// failed attempt and for every game which closed within this time, which prevents a crashing game from being restarted in a busy loop.
+	maxAutostartDelay = time.Minute
+)
+
+// GamePreset describes a persistent public game created at startup, see AutostartGames.
+type GamePreset struct {
+	// The name clients can find the game by in /api/games, e.g. "beginner" or "ranked".
+	Name string `json:"name"`
+	// The name of a preset registered with RegisterPreset whose config is used. (empty => Config)
+	PresetName string `json:"preset,omitempty"`
+	// The config of the game.
+	Config json.RawMessage `json:"config,omitempty"`
+}
+
+// AutostartName returns the name of the GamePreset the game was created for (empty => not an autostart game).
+func (g *Game) AutostartName() string {
+	return g.autostart.Name
+}
+
+// StartAutostartGames creates the games of AutostartGames. Run and RunContext call it once the listener is bound.
+// When serving Handler with a custom webserver, call it after the webserver has started listening.
+// Only the first call has an effect.
+func (s *Server) StartAutostartGames() {
+	s.autostartOnce.Do(func() {
+		for _, preset := range s.config.AutostartGames {
+			s.startAutostartGame(preset, autostartDelay)
+		}
+	})
+}
+
+// startAutostartGame creates a public game for preset and retries after delay with a doubled delay if that fails.
+func (s *Server) startAutostartGame(preset GamePreset, delay time.Duration) {
+	if s.closed() {
+		return
+	}
+
+	config := preset.Config
+	if preset.PresetName != "" {
+		var ok bool
+		config, ok = s.getPreset(preset.PresetName)
+		if !ok {
+			s.log.Error("Failed to create autostart game '%s': preset '%s' not found", preset.Name, preset.PresetName)
+			return
+		}
+	}
+
+	id, _, err := s.createGameWith(true, false, false, config, func(game *Game) {
+		game.autostart = preset
+		game.autostartDelay = delay
+	})
+	if err != nil {
+		s.log.Error("Failed to create autostart game '%s', retrying in %s: %s", preset.Name, delay, err)
+		if errors.Is(err, ErrGameInitFailed) {
+			// the game has been closed, so restartAutostartGame recreates it
+			return
+		}
+		s.config.Clock.AfterFunc(delay, func() {
+			s.startAutostartGame(preset, nextAutostartDelay(delay))
+		})
+		return
+	}
+	s.log.Info("Created autostart game '%s' with id %s.", preset.Name, id)
+}
+
+// restartAutostartGame recreates the closed game if it has been created for a GamePreset.
+// A game which closed within maxAutostartDelay after it was created is recreated with a doubled delay.
+func (s *Server) restartAutostartGame(game *Game) {
+	if game.autostart.Name == "" || s.closed() {
+		return
+	}
+	delay := autostartDelay
+	if s.config.Clock.Now().Sub(game.createdAt) < maxAutostartDelay {
+		delay = game.autostartDelay
+	}
+	s.config.Clock.AfterFunc(delay, func() {
+		s.startAutostartGame(game.autostart, nextAutostartDelay(delay))
+	})
+}
+
+func nextAutostartDelay(delay time.Duration) time.Duration {
+	delay *= 2
+	if delay > maxAutostartDelay {
+		return maxAutostartDelay
+	}
+	return delay
+}
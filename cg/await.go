@@ -0,0 +1,65 @@
+package cg
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrGameClosed = errors.New("game closed")
+
+// AwaitCommand waits for the next command with the given name sent by from (nil => any player) and returns it.
+// Other commands received in the meantime are kept in order and returned by later calls to NextCommand,
+// WaitForNextCommand or AwaitCommand. Functions scheduled with Schedule or ScheduleAt are executed while waiting.
+// It returns the error of ctx if ctx is done first or ErrGameClosed if the game has been closed.
+func (g *Game) AwaitCommand(ctx context.Context, name CommandName, from *Player) (CommandWrapper, error) {
+	g.markStarted()
+	matches := func(wrapper CommandWrapper) bool {
+		return wrapper.Cmd.Name == name && (from == nil || wrapper.Origin == from)
+	}
+
+	g.pendingLock.Lock()
+	for i, wrapper := range g.pending {
+		if matches(wrapper) {
+			g.pending = append(g.pending[:i], g.pending[i+1:]...)
+			g.pendingLock.Unlock()
+			return wrapper, nil
+		}
+	}
+	g.pendingLock.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return CommandWrapper{}, ctx.Err()
+		case wrapper, ok := <-g.cmdChan:
+			if !ok {
+				return CommandWrapper{}, ErrGameClosed
+			}
+			g.markConsumed()
+			if wrapper.scheduled != nil {
+				wrapper.scheduled()
+				continue
+			}
+			g.countCommand()
+			wrapper.endSpan()
+			if matches(wrapper) {
+				return wrapper, nil
+			}
+			g.pendingLock.Lock()
+			g.pending = append(g.pending, wrapper)
+			g.pendingLock.Unlock()
+		}
+	}
+}
+
+// nextPending removes and returns the oldest command buffered by AwaitCommand.
+func (g *Game) nextPending() (CommandWrapper, bool) {
+	g.pendingLock.Lock()
+	defer g.pendingLock.Unlock()
+	if len(g.pending) == 0 {
+		return CommandWrapper{}, false
+	}
+	wrapper := g.pending[0]
+	g.pending = g.pending[1:]
+	return wrapper, true
+}
@@ -0,0 +1,143 @@
+package cg
+
+import (
+	"sync"
+	"time"
+)
+
+// bandwidth counts the bytes sent to and received from a socket, player or game.
+type bandwidth struct {
+	lock        sync.Mutex
+	sent        int64
+	received    int64
+	windowStart time.Time
+	windowSent  int64
+}
+
+func (b *bandwidth) addSent(n int, now time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.sent += int64(n)
+	if now.Sub(b.windowStart) >= time.Second {
+		b.windowStart = now
+		b.windowSent = 0
+	}
+	b.windowSent += int64(n)
+}
+
+func (b *bandwidth) addReceived(n int) {
+	b.lock.Lock()
+	b.received += int64(n)
+	b.lock.Unlock()
+}
+
+// exceeded returns true if more than limit bytes have been sent during the current second (limit <= 0 => unlimited).
+func (b *bandwidth) exceeded(limit int, now time.Time) bool {
+	if limit <= 0 {
+		return false
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return now.Sub(b.windowStart) < time.Second && b.windowSent >= int64(limit)
+}
+
+func (b *bandwidth) totals() (int64, int64) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.sent, b.received
+}
+
+// BytesSent returns the number of bytes sent to the sockets of the player.
+func (p *Player) BytesSent() int64 {
+	sent, _ := p.bandwidth.totals()
+	return sent
+}
+
+// BytesReceived returns the number of bytes received from the sockets of the player.
+func (p *Player) BytesReceived() int64 {
+	_, received := p.bandwidth.totals()
+	return received
+}
+
+// BytesSent returns the number of bytes sent to the socket.
+func (s *GameSocket) BytesSent() int64 {
+	sent, _ := s.bandwidth.totals()
+	return sent
+}
+
+// BytesReceived returns the number of bytes received from the socket.
+func (s *GameSocket) BytesReceived() int64 {
+	_, received := s.bandwidth.totals()
+	return received
+}
+
+// BytesSent returns the number of bytes sent to all players and spectators of the game.
+func (g *Game) BytesSent() int64 {
+	sent, _ := g.bandwidth.totals()
+	return sent
+}
+
+// BytesReceived returns the number of bytes received from all players of the game.
+func (g *Game) BytesReceived() int64 {
+	_, received := g.bandwidth.totals()
+	return received
+}
+
+// SetLowPriority marks the events as low-priority.
+// Low-priority events are dropped instead of sent while a player or game exceeds
+// MaxBandwidthPerPlayer or MaxBandwidthPerGame.
+func (g *Game) SetLowPriority(events ...EventName) {
+	g.lowPriorityLock.Lock()
+	defer g.lowPriorityLock.Unlock()
+	for _, e := range events {
+		g.lowPriority[e] = struct{}{}
+	}
+}
+
+func (g *Game) isLowPriority(event EventName) bool {
+	g.lowPriorityLock.RLock()
+	defer g.lowPriorityLock.RUnlock()
+	_, ok := g.lowPriority[event]
+	return ok
+}
+
+func (s *GameSocket) game() *Game {
+	if s.player != nil {
+		return s.player.game
+	}
+	return s.spectateGame
+}
+
+// shed returns true if the event should be dropped because the socket's player or game exceeds its bandwidth cap.
+func (s *GameSocket) shed(event EventName) bool {
+	game := s.game()
+	if game == nil || !game.isLowPriority(event) {
+		return false
+	}
+	now := s.server.config.Clock.Now()
+	if s.player != nil && s.player.bandwidth.exceeded(s.server.config.MaxBandwidthPerPlayer, now) {
+		return true
+	}
+	return game.bandwidth.exceeded(s.server.config.MaxBandwidthPerGame, now)
+}
+
+func (s *GameSocket) countSent(n int) {
+	now := s.server.config.Clock.Now()
+	s.bandwidth.addSent(n, now)
+	if s.player != nil {
+		s.player.bandwidth.addSent(n, now)
+	}
+	if game := s.game(); game != nil {
+		game.bandwidth.addSent(n, now)
+	}
+}
+
+func (s *GameSocket) countReceived(n int) {
+	s.bandwidth.addReceived(n)
+	if s.player != nil {
+		s.player.bandwidth.addReceived(n)
+	}
+	if game := s.game(); game != nil {
+		game.bandwidth.addReceived(n)
+	}
+}
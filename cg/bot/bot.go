@@ -0,0 +1,209 @@
+/*
+Package bot implements the client side of the CodeGame protocol for automated tests and simple AI opponents.
+*/
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/code-game-project/go-server/cg"
+	"github.com/code-game-project/go-server/cg/internal/apiclient"
+)
+
+var ErrNotJoined = errors.New("bot has not joined a game")
+
+type Bot struct {
+	// The base URL of the game server, e.g. http://localhost:8080.
+	URL string
+
+	GameID       string
+	PlayerID     string
+	PlayerSecret string
+
+	// The number of reconnect attempts after an unexpected disconnect. (default: 3)
+	ReconnectAttempts int
+	// The time to wait between reconnect attempts. (default: 1 second)
+	ReconnectDelay time.Duration
+
+	// OnDisconnect is called whenever the socket is closed unexpectedly before a reconnect is attempted.
+	OnDisconnect func(err error)
+
+	handlersLock sync.RWMutex
+	handlers     map[cg.EventName][]func(event cg.Event)
+
+	connLock sync.Mutex
+	conn     *websocket.Conn
+	closed   bool
+}
+
+// New creates a new bot for the game server at serverURL.
+func New(serverURL string) *Bot {
+	if !strings.HasPrefix(serverURL, "http://") && !strings.HasPrefix(serverURL, "https://") {
+		serverURL = "http://" + serverURL
+	}
+	return &Bot{
+		URL:               strings.TrimSuffix(serverURL, "/"),
+		ReconnectAttempts: 3,
+		ReconnectDelay:    time.Second,
+		handlers:          make(map[cg.EventName][]func(event cg.Event)),
+	}
+}
+
+// CreateGame creates a new game on the server and returns its ID and join secret.
+func (b *Bot) CreateGame(public, protected bool, config any) (string, string, error) {
+	return apiclient.CreateGame(b.URL, public, protected, config)
+}
+
+// Join creates a new player in the game and connects to it.
+func (b *Bot) Join(gameID, username, joinSecret string) error {
+	playerID, playerSecret, err := apiclient.CreatePlayer(b.URL, gameID, username, joinSecret)
+	if err != nil {
+		return err
+	}
+	b.GameID = gameID
+	b.PlayerID = playerID
+	b.PlayerSecret = playerSecret
+	return b.Connect()
+}
+
+// Connect connects a new socket to the player of the bot.
+// Use it to reconnect to a player with known credentials.
+func (b *Bot) Connect() error {
+	if b.GameID == "" || b.PlayerID == "" || b.PlayerSecret == "" {
+		return ErrNotJoined
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(apiclient.ConnectURL(b.URL, b.GameID, b.PlayerID, b.PlayerSecret), nil)
+	if err != nil {
+		return err
+	}
+
+	b.connLock.Lock()
+	if b.conn != nil {
+		b.conn.Close()
+	}
+	b.conn = conn
+	b.closed = false
+	b.connLock.Unlock()
+	return nil
+}
+
+// On registers a handler which is called for every received event with the given name.
+func (b *Bot) On(event cg.EventName, handler func(event cg.Event)) {
+	b.handlersLock.Lock()
+	b.handlers[event] = append(b.handlers[event], handler)
+	b.handlersLock.Unlock()
+}
+
+// Handle registers a handler which is called with the decoded data of every received event with the given name.
+func Handle[T any](b *Bot, event cg.EventName, handler func(data T)) {
+	b.On(event, func(e cg.Event) {
+		var data T
+		if err := json.Unmarshal(e.Data, &data); err != nil {
+			return
+		}
+		handler(data)
+	})
+}
+
+// Send sends a command to the server.
+func (b *Bot) Send(cmd cg.CommandName, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	b.connLock.Lock()
+	defer b.connLock.Unlock()
+	if b.conn == nil {
+		return ErrNotJoined
+	}
+	return b.conn.WriteJSON(cg.Command{
+		Name: cmd,
+		Data: encoded,
+	})
+}
+
+// Run receives events and calls the registered handlers until the connection is closed.
+// Unexpected disconnects are answered with reconnect attempts.
+// It returns nil if the connection was closed by Close or by the server.
+func (b *Bot) Run() error {
+	for {
+		err := b.receive()
+		if err == nil {
+			return nil
+		}
+
+		if b.OnDisconnect != nil {
+			b.OnDisconnect(err)
+		}
+
+		if err = b.reconnect(); err != nil {
+			return err
+		}
+	}
+}
+
+// Close disconnects the bot without leaving the game.
+func (b *Bot) Close() error {
+	b.connLock.Lock()
+	defer b.connLock.Unlock()
+	if b.conn == nil {
+		return nil
+	}
+	b.closed = true
+	b.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(5*time.Second))
+	return b.conn.Close()
+}
+
+func (b *Bot) receive() error {
+	b.connLock.Lock()
+	conn := b.conn
+	b.connLock.Unlock()
+	if conn == nil {
+		return ErrNotJoined
+	}
+
+	for {
+		var event cg.Event
+		err := conn.ReadJSON(&event)
+		if err != nil {
+			b.connLock.Lock()
+			closed := b.closed
+			b.connLock.Unlock()
+			if closed || websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				return nil
+			}
+			return err
+		}
+
+		b.handlersLock.RLock()
+		handlers := b.handlers[event.Name]
+		b.handlersLock.RUnlock()
+		for _, h := range handlers {
+			h(event)
+		}
+	}
+}
+
+func (b *Bot) reconnect() error {
+	var err error
+	for i := 0; i < b.ReconnectAttempts; i++ {
+		time.Sleep(b.ReconnectDelay)
+		err = b.Connect()
+		if err == nil {
+			return nil
+		}
+	}
+	if err == nil {
+		err = errors.New("connection lost")
+	}
+	return fmt.Errorf("reconnect failed: %w", err)
+}
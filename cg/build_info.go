@@ -0,0 +1,92 @@
+package cg
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sort"
+)
+
+// buildInfoEndpoint describes the running server binary and its enabled subsystems for bug reports.
+func (s *Server) buildInfoEndpoint(w http.ResponseWriter, r *http.Request) {
+	type module struct {
+		Path    string `json:"path"`
+		Version string `json:"version"`
+	}
+	type response struct {
+		GoVersion string `json:"go_version"`
+		// The main module of the binary.
+		Module module `json:"module"`
+		// The version of this package (empty => unknown).
+		ServerVersion string `json:"server_version,omitempty"`
+		GameVersion   string `json:"game_version,omitempty"`
+		CGVersion     string `json:"cg_version"`
+		// The VCS revision the binary was built from (empty => unknown).
+		Revision string `json:"revision,omitempty"`
+		// The revision had uncommitted changes.
+		Modified bool `json:"modified,omitempty"`
+		// The time at which the server was started in unix milliseconds.
+		StartedAt  int64    `json:"started_at"`
+		Subsystems []string `json:"subsystems"`
+	}
+
+	res := response{
+		GoVersion:   runtime.Version(),
+		GameVersion: s.config.Version,
+		CGVersion:   CGVersion,
+		StartedAt:   s.startedAt.UnixMilli(),
+		Subsystems:  s.enabledSubsystems(),
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		res.Module = module{
+			Path:    info.Main.Path,
+			Version: info.Main.Version,
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == "github.com/code-game-project/go-server" {
+				res.ServerVersion = dep.Version
+				if dep.Replace != nil {
+					res.ServerVersion = dep.Replace.Version
+				}
+			}
+		}
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				res.Revision = setting.Value
+			case "vcs.modified":
+				res.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	sendJSON(w, http.StatusOK, res)
+}
+
+// enabledSubsystems returns the names of the optional subsystems enabled in the config.
+func (s *Server) enabledSubsystems() []string {
+	subsystems := make([]string, 0)
+	add := func(enabled bool, name string) {
+		if enabled {
+			subsystems = append(subsystems, name)
+		}
+	}
+	add(s.config.TCPPort > 0, "tcp")
+	add(s.config.TLSConfig != nil || s.config.TLSCertFile != "", "tls")
+	add(s.config.Frontend != nil, "frontend")
+	add(s.config.EnableWebUI, "web_ui")
+	add(s.config.AdminToken != "", "admin")
+	add(s.config.AuthenticateRequest != nil, "trial_games")
+	add(s.config.ZombieTimeout > 0, "zombie_detection")
+	add(len(s.config.Notifications) > 0, "notifications")
+	add(s.config.EventJournalSize > 0, "event_journal")
+	add(s.config.ReconnectGracePeriod > 0, "reconnect_grace_period")
+	add(s.config.MaxBandwidthPerPlayer > 0 || s.config.MaxBandwidthPerGame > 0, "bandwidth_limits")
+	add(s.config.SpectatorQueueSize > 0, "spectator_queues")
+	add(s.config.Transport != nil, "custom_transport")
+	add(s.config.OnRawMessage != nil, "raw_message_hook")
+	add(len(s.config.Locales) > 0, "locales")
+	sort.Strings(subsystems)
+	return subsystems
+}
@@ -0,0 +1,227 @@
+package cg
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// cgeFile is the parsed content of a CGE file, which describes the commands, events and types of a game.
+type cgeFile struct {
+	Name    string
+	Version string
+	Objects []cgeObject
+}
+
+type cgeObject struct {
+	// config, command, event, type or enum
+	Kind   string
+	Name   string
+	Doc    string
+	Fields []cgeField
+}
+
+type cgeField struct {
+	Name string
+	// The type of the field (empty for enum values).
+	Type string
+	Doc  string
+}
+
+type cgeToken struct {
+	text string
+	// the doc comment preceding the token
+	doc  string
+	line int
+}
+
+// parseCGE parses the subset of the CGE language needed to document and check the events of a game.
+func parseCGE(source string) (cgeFile, error) {
+	tokens := tokenizeCGE(source)
+	var file cgeFile
+
+	next := func(i *int) (cgeToken, error) {
+		if *i >= len(tokens) {
+			return cgeToken{}, fmt.Errorf("unexpected end of file")
+		}
+		t := tokens[*i]
+		*i++
+		return t, nil
+	}
+
+	for i := 0; i < len(tokens); {
+		t, _ := next(&i)
+		switch t.text {
+		case "name", "version":
+			value, err := next(&i)
+			if err != nil {
+				return cgeFile{}, err
+			}
+			if t.text == "name" {
+				file.Name = value.text
+			} else {
+				file.Version = value.text
+			}
+		case "config", "command", "event", "type", "enum":
+			obj := cgeObject{
+				Kind: t.text,
+				Doc:  t.doc,
+			}
+			if t.text == "config" {
+				obj.Name = "config"
+			} else {
+				name, err := next(&i)
+				if err != nil {
+					return cgeFile{}, err
+				}
+				obj.Name = name.text
+			}
+
+			open, err := next(&i)
+			if err != nil {
+				return cgeFile{}, err
+			}
+			if open.text != "{" {
+				return cgeFile{}, fmt.Errorf("line %d: expected '{' after %s %s", open.line, obj.Kind, obj.Name)
+			}
+
+			fields, err := parseCGEFields(tokens, &i, obj.Kind == "enum")
+			if err != nil {
+				return cgeFile{}, err
+			}
+			obj.Fields = fields
+			file.Objects = append(file.Objects, obj)
+		default:
+			return cgeFile{}, fmt.Errorf("line %d: unexpected '%s'", t.line, t.text)
+		}
+	}
+
+	return file, nil
+}
+
+// parseCGEFields parses the fields of an object up to and including the closing brace.
+func parseCGEFields(tokens []cgeToken, i *int, enum bool) ([]cgeField, error) {
+	fields := make([]cgeField, 0)
+	for *i < len(tokens) {
+		t := tokens[*i]
+		*i++
+		switch t.text {
+		case "}":
+			return fields, nil
+		case ",":
+			continue
+		}
+
+		field := cgeField{
+			Name: t.text,
+			Doc:  t.doc,
+		}
+		if !enum {
+			if *i >= len(tokens) || tokens[*i].text != ":" {
+				return nil, fmt.Errorf("line %d: expected ':' after field %s", t.line, t.text)
+			}
+			*i++
+			typ := make([]string, 0, 1)
+			depth := 0
+			for *i < len(tokens) {
+				tt := tokens[*i].text
+				if depth == 0 && (tt == "," || tt == "}") {
+					break
+				}
+				if tt == "<" {
+					depth++
+				} else if tt == ">" {
+					depth--
+				}
+				typ = append(typ, tt)
+				*i++
+			}
+			field.Type = strings.Join(typ, "")
+			if field.Type == "" {
+				return nil, fmt.Errorf("line %d: missing type of field %s", t.line, t.text)
+			}
+		}
+		fields = append(fields, field)
+	}
+	return nil, fmt.Errorf("unexpected end of file")
+}
+
+func tokenizeCGE(source string) []cgeToken {
+	tokens := make([]cgeToken, 0)
+	doc := make([]string, 0)
+	line := 1
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '\n':
+			line++
+			i++
+		case unicode.IsSpace(r):
+			i++
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			start := i + 2
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			doc = append(doc, strings.TrimSpace(string(runes[start:i])))
+		case strings.ContainsRune("{}:,<>?", r):
+			tokens = append(tokens, cgeToken{text: string(r), line: line})
+			i++
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune("{}:,<>?/", runes[i]) {
+				i++
+			}
+			if i == start {
+				// a single '/' which does not start a comment
+				i++
+			}
+			tokens = append(tokens, cgeToken{text: string(runes[start:i]), doc: strings.Join(doc, "\n"), line: line})
+			doc = doc[:0]
+		}
+	}
+
+	return tokens
+}
+
+// standardEvents contains the cg_* events and commands defined by this package and its subpackages.
+// Every EventName and CommandName constant with the cg_ prefix must be listed here,
+// because ValidateEvents and the events page rely on it.
+var standardEvents = []cgeObject{
+	{Kind: "event", Name: string(EventError), Doc: "Sent to a socket whose command could not be handled.", Fields: []cgeField{{Name: "code", Type: "string"}, {Name: "message", Type: "string"}, {Name: "command", Type: "string?"}}},
+	{Kind: "event", Name: string(EventNotification), Doc: "A message which frontends display to the user.", Fields: []cgeField{{Name: "level", Type: "string"}, {Name: "title", Type: "string?"}, {Name: "message", Type: "string"}, {Name: "ttl", Type: "int64?", Doc: "Milliseconds until the notification should be hidden."}}},
+	{Kind: "event", Name: string(EventServerAnnouncement), Doc: "An announcement of the server operator.", Fields: []cgeField{{Name: "level", Type: "string"}, {Name: "message", Type: "string"}}},
+	{Kind: "event", Name: string(EventResync), Doc: "A snapshot of the game state sent to reconnecting sockets and new spectators."},
+	{Kind: "event", Name: string(EventMissedEvents), Doc: "The events a player missed while it had no sockets.", Fields: []cgeField{{Name: "events", Type: "list<event>"}}},
+	{Kind: "event", Name: string(EventPlayerDisconnected), Doc: "A player lost all of its sockets.", Fields: []cgeField{{Name: "player", Type: "string"}}},
+	{Kind: "event", Name: string(EventSettingsChanged), Doc: "The host changed the settings of the game."},
+	{Kind: "event", Name: string(EventVoteStarted), Doc: "A vote has been started.", Fields: []cgeField{{Name: "vote", Type: "string"}, {Name: "question", Type: "string"}, {Name: "options", Type: "list<string>"}, {Name: "deadline", Type: "int64"}}},
+	{Kind: "event", Name: string(EventVoteEnded), Doc: "A vote has ended.", Fields: []cgeField{{Name: "vote", Type: "string"}, {Name: "question", Type: "string"}, {Name: "options", Type: "list<string>"}, {Name: "counts", Type: "list<int>"}, {Name: "winner", Type: "int"}}},
+	{Kind: "event", Name: string(EventTimer), Doc: "The state of a timer of the player changed.", Fields: []cgeField{{Name: "name", Type: "string"}, {Name: "state", Type: "string"}, {Name: "remaining", Type: "int64"}}},
+	{Kind: "event", Name: string(EventInvite), Doc: "An invite to another game.", Fields: []cgeField{{Name: "invite", Type: "string"}, {Name: "game_id", Type: "string"}}},
+	{Kind: "event", Name: string(EventInviteAccepted), Doc: "The credentials of an accepted invite.", Fields: []cgeField{{Name: "game_id", Type: "string"}, {Name: "player_id", Type: "string"}, {Name: "player_secret", Type: "string"}}},
+	{Kind: "event", Name: string(EventKeyframe), Doc: "A snapshot of the game state for keyframe spectators."},
+	{Kind: "event", Name: string(EventClosing), Doc: "The game will be closed at the deadline.", Fields: []cgeField{{Name: "deadline", Type: "int64"}}},
+	{Kind: "event", Name: string(EventClosed), Doc: "The final results of the game.", Fields: []cgeField{{Name: "results", Type: "any?"}}},
+	{Kind: "event", Name: string(EventAuthenticated), Doc: "The TCP socket has been authenticated.", Fields: []cgeField{{Name: "socket", Type: "string"}}},
+	{Kind: "event", Name: string(EventAuthenticationFailed), Doc: "The TCP socket could not be authenticated and will be closed.", Fields: []cgeField{{Name: "message", Type: "string"}}},
+	{Kind: "event", Name: string(EventRoomMembers), Doc: "The members of the room changed.", Fields: []cgeField{{Name: "host", Type: "string"}, {Name: "members", Type: "map<string>"}}},
+	{Kind: "event", Name: string(EventRoomChat), Doc: "A chat message of a room member.", Fields: []cgeField{{Name: "member", Type: "string"}, {Name: "username", Type: "string"}, {Name: "message", Type: "string"}}},
+	{Kind: "event", Name: string(EventRoomGameCreated), Doc: "The credentials of the member in the game created by the room host.", Fields: []cgeField{{Name: "game_id", Type: "string"}, {Name: "join_secret", Type: "string?"}, {Name: "player_id", Type: "string"}, {Name: "player_secret", Type: "string"}}},
+	// defined by the turns package, which imports this package
+	{Kind: "event", Name: "cg_turn", Doc: "A new turn began.", Fields: []cgeField{{Name: "player", Type: "string"}, {Name: "turn", Type: "int"}, {Name: "round", Type: "int"}, {Name: "deadline", Type: "int64?"}}},
+	{Kind: "command", Name: string(CommandSubscribe), Doc: "Only receive the listed events.", Fields: []cgeField{{Name: "events", Type: "list<string>"}}},
+	{Kind: "command", Name: string(CommandUnsubscribe), Doc: "Stop receiving the listed events.", Fields: []cgeField{{Name: "events", Type: "list<string>"}}},
+	{Kind: "command", Name: string(CommandVote), Doc: "Cast a ballot in a running vote.", Fields: []cgeField{{Name: "vote", Type: "string"}, {Name: "option", Type: "int"}}},
+	{Kind: "command", Name: string(CommandUpdateSettings), Doc: "Update the settings of the game (host only)."},
+	{Kind: "command", Name: string(CommandInviteAccept), Doc: "Accept an invite.", Fields: []cgeField{{Name: "invite", Type: "string"}}},
+	{Kind: "command", Name: string(CommandInviteDecline), Doc: "Decline an invite.", Fields: []cgeField{{Name: "invite", Type: "string"}}},
+	{Kind: "command", Name: string(CommandClosingAck), Doc: "Acknowledge cg_closing after sending the final commands."},
+	{Kind: "command", Name: string(CommandSpectate), Doc: "Start spectating a game (multi-game spectator sockets only).", Fields: []cgeField{{Name: "game_id", Type: "string"}}},
+	{Kind: "command", Name: string(CommandUnspectate), Doc: "Stop spectating a game (multi-game spectator sockets only).", Fields: []cgeField{{Name: "game_id", Type: "string"}}},
+	{Kind: "command", Name: string(CommandAuthenticate), Doc: "Authenticate a TCP socket as a player or spectator.", Fields: []cgeField{{Name: "cg_version", Type: "string?"}, {Name: "game_id", Type: "string"}, {Name: "spectate", Type: "bool?"}, {Name: "tier", Type: "string?"}, {Name: "player_id", Type: "string?"}, {Name: "player_secret", Type: "string?"}}},
+	{Kind: "command", Name: string(CommandRoomChat), Doc: "Send a chat message to all room members.", Fields: []cgeField{{Name: "message", Type: "string"}}},
+	{Kind: "command", Name: string(CommandRoomCreateGame), Doc: "Create a game which all connected room members join (room host only).", Fields: []cgeField{{Name: "public", Type: "bool"}, {Name: "protected", Type: "bool"}, {Name: "config", Type: "any?"}}},
+}
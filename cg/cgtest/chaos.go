@@ -0,0 +1,125 @@
+package cgtest
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/code-game-project/go-server/cg"
+)
+
+// Chaos is a cg.Transport which simulates bad network conditions on all game sockets of a server.
+// Use Configure to change its fields while the server is running.
+type Chaos struct {
+	lock sync.Mutex
+
+	// The probability that a message is never delivered.
+	DropRate float64
+	// The probability that a message is delivered twice.
+	DuplicateRate float64
+	// The probability that a message is held back and delivered after the next message to the same socket.
+	ReorderRate float64
+	// The delay of every message.
+	Delay time.Duration
+	// The maximum random delay added to Delay.
+	Jitter time.Duration
+
+	rand    *rand.Rand
+	sockets map[string]*chaosSocket
+}
+
+type chaosSocket struct {
+	socket *cg.GameSocket
+	held   []byte
+}
+
+// NewChaos returns a Chaos transport which makes its random decisions based on seed.
+// Pass it to the server with cg.ServerConfig.Transport.
+func NewChaos(seed int64) *Chaos {
+	return &Chaos{
+		rand:    rand.New(rand.NewSource(seed)),
+		sockets: make(map[string]*chaosSocket),
+	}
+}
+
+// Configure calls fn while no messages are being sent so that it can safely change the fields of c.
+func (c *Chaos) Configure(fn func(c *Chaos)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	fn(c)
+}
+
+func (c *Chaos) Send(socket *cg.GameSocket, message []byte, write func(message []byte) error) error {
+	c.lock.Lock()
+	s, ok := c.sockets[socket.ID]
+	if !ok {
+		s = &chaosSocket{socket: socket}
+		c.sockets[socket.ID] = s
+	}
+
+	if c.rand.Float64() < c.DropRate {
+		c.lock.Unlock()
+		return nil
+	}
+
+	messages := [][]byte{message}
+	if c.rand.Float64() < c.DuplicateRate {
+		messages = append(messages, message)
+	}
+
+	if s.held != nil {
+		messages = append(messages, s.held)
+		s.held = nil
+	} else if c.rand.Float64() < c.ReorderRate {
+		s.held = message
+		messages = messages[1:]
+	}
+
+	delay := c.Delay
+	if c.Jitter > 0 {
+		delay += time.Duration(c.rand.Int63n(int64(c.Jitter)))
+	}
+	c.lock.Unlock()
+
+	if delay <= 0 {
+		for _, m := range messages {
+			if err := write(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	time.AfterFunc(delay, func() {
+		for _, m := range messages {
+			if write(m) != nil {
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// Disconnect drops the connection of the socket with the given ID as if the network connection was lost.
+// It returns false if no message has been sent to the socket yet.
+func (c *Chaos) Disconnect(socketID string) bool {
+	c.lock.Lock()
+	s, ok := c.sockets[socketID]
+	delete(c.sockets, socketID)
+	c.lock.Unlock()
+	if ok {
+		s.socket.Disconnect()
+	}
+	return ok
+}
+
+// DisconnectAll drops the connections of all sockets which have received a message.
+func (c *Chaos) DisconnectAll() {
+	c.lock.Lock()
+	sockets := c.sockets
+	c.sockets = make(map[string]*chaosSocket)
+	c.lock.Unlock()
+	for _, s := range sockets {
+		s.socket.Disconnect()
+	}
+}
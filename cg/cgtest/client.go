@@ -0,0 +1,153 @@
+package cgtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/code-game-project/go-server/cg"
+)
+
+// The time Expect waits for an event before failing the test.
+var DefaultTimeout = 5 * time.Second
+
+var ErrTimeout = errors.New("timed out waiting for event")
+
+type TestClient struct {
+	GameID       string
+	PlayerID     string
+	PlayerSecret string
+
+	conn   *websocket.Conn
+	events chan cg.Event
+	done   chan struct{}
+
+	server *TestServer
+	alias  string
+}
+
+func (s *TestServer) dial(url string) (*TestClient, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &TestClient{
+		conn:   conn,
+		events: make(chan cg.Event, 256),
+		done:   make(chan struct{}),
+		server: s,
+	}
+	go client.readEvents()
+	return client, nil
+}
+
+func (c *TestClient) readEvents() {
+	defer close(c.done)
+	for {
+		var event cg.Event
+		err := c.conn.ReadJSON(&event)
+		if err != nil {
+			return
+		}
+		c.events <- event
+	}
+}
+
+// Send sends a command to the server.
+func (c *TestClient) Send(name cg.CommandName, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	err = c.conn.WriteJSON(cg.Command{
+		Name: name,
+		Data: encoded,
+	})
+	if err == nil {
+		c.server.recordSend(c, name, encoded)
+	}
+	return err
+}
+
+// SendRaw sends message as a text message without encoding it, e.g. to test how the server handles malformed commands.
+func (c *TestClient) SendRaw(message []byte) error {
+	return c.conn.WriteMessage(websocket.TextMessage, message)
+}
+
+// NextEvent returns the next received event or ErrTimeout if no event arrives within timeout.
+func (c *TestClient) NextEvent(timeout time.Duration) (cg.Event, error) {
+	select {
+	case event := <-c.events:
+		return event, nil
+	case <-c.done:
+		select {
+		case event := <-c.events:
+			return event, nil
+		default:
+			return cg.Event{}, errors.New("connection closed")
+		}
+	case <-time.After(timeout):
+		return cg.Event{}, ErrTimeout
+	}
+}
+
+// WaitForEvent skips all events until an event with the given name is received.
+func (c *TestClient) WaitForEvent(name cg.EventName, timeout time.Duration) (cg.Event, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		event, err := c.NextEvent(time.Until(deadline))
+		if err != nil {
+			return cg.Event{}, fmt.Errorf("waiting for '%s': %w", name, err)
+		}
+		if event.Name == name {
+			return event, nil
+		}
+	}
+}
+
+// Expect fails the test if the next event is not called name.
+// The event data is decoded into the value pointed to by dataPtr unless it is nil.
+func (c *TestClient) Expect(t testing.TB, name cg.EventName, dataPtr any) {
+	t.Helper()
+	event, err := c.NextEvent(DefaultTimeout)
+	if err != nil {
+		t.Fatalf("expected '%s' event: %s", name, err)
+	}
+	if event.Name != name {
+		t.Fatalf("expected '%s' event, got '%s'", name, event.Name)
+	}
+	if dataPtr != nil {
+		err = json.Unmarshal(event.Data, dataPtr)
+		if err != nil {
+			t.Fatalf("failed to decode '%s' event: %s", name, err)
+		}
+	}
+}
+
+// ExpectNone fails the test if an event is received within d.
+func (c *TestClient) ExpectNone(t testing.TB, d time.Duration) {
+	t.Helper()
+	event, err := c.NextEvent(d)
+	if err == nil {
+		t.Fatalf("expected no event, got '%s'", event.Name)
+	}
+}
+
+// Close disconnects the socket and waits up to DefaultTimeout until the server has closed the connection,
+// after which the server no longer sends events to the socket.
+func (c *TestClient) Close() error {
+	c.server.recordDisconnect(c)
+	err := c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	if err == nil {
+		select {
+		case <-c.done:
+		case <-time.After(DefaultTimeout):
+		}
+	}
+	return c.conn.Close()
+}
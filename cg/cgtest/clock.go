@@ -0,0 +1,142 @@
+package cgtest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/code-game-project/go-server/cg"
+)
+
+// FakeClock is a cg.Clock which only moves forward when Advance is called.
+type FakeClock struct {
+	lock    sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+type fakeWaiter struct {
+	clock   *FakeClock
+	next    time.Time
+	period  time.Duration
+	c       chan time.Time
+	fn      func()
+	stopped bool
+}
+
+// NewFakeClock returns a FakeClock set to start.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{
+		now: start,
+	}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) NewTicker(d time.Duration) cg.Ticker {
+	if d <= 0 {
+		panic("non-positive interval for FakeClock.NewTicker")
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	w := &fakeWaiter{
+		clock:  c,
+		next:   c.now.Add(d),
+		period: d,
+		c:      make(chan time.Time, 1),
+	}
+	c.waiters = append(c.waiters, w)
+	return fakeTicker{w}
+}
+
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) cg.Timer {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	w := &fakeWaiter{
+		clock: c,
+		next:  c.now.Add(d),
+		fn:    f,
+	}
+	c.waiters = append(c.waiters, w)
+	return fakeTimer{w}
+}
+
+// Advance moves the clock forward by d and fires all tickers and timers which become due in chronological order.
+// Timer functions are called synchronously.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	target := c.now.Add(d)
+	for {
+		var due *fakeWaiter
+		for _, w := range c.waiters {
+			if !w.stopped && !w.next.After(target) && (due == nil || w.next.Before(due.next)) {
+				due = w
+			}
+		}
+		if due == nil {
+			break
+		}
+
+		c.now = due.next
+		if due.period > 0 {
+			due.next = due.next.Add(due.period)
+			select {
+			case due.c <- c.now:
+			default:
+			}
+			continue
+		}
+
+		due.stopped = true
+		c.removeStopped()
+		c.lock.Unlock()
+		due.fn()
+		c.lock.Lock()
+	}
+	c.now = target
+	c.removeStopped()
+	c.lock.Unlock()
+}
+
+func (c *FakeClock) removeStopped() {
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if !w.stopped {
+			waiters = append(waiters, w)
+		}
+	}
+	c.waiters = waiters
+}
+
+type fakeTicker struct {
+	*fakeWaiter
+}
+
+type fakeTimer struct {
+	*fakeWaiter
+}
+
+func (t fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t fakeTicker) Stop() {
+	t.stop()
+}
+
+func (t fakeTimer) Stop() bool {
+	return t.stop()
+}
+
+func (w *fakeWaiter) stop() bool {
+	w.clock.lock.Lock()
+	defer w.clock.lock.Unlock()
+	if w.stopped {
+		return false
+	}
+	w.stopped = true
+	return true
+}
@@ -0,0 +1,123 @@
+/*
+Package cgtest provides helpers for testing CodeGame game logic with real clients connected over an in-process test server.
+*/
+package cgtest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/code-game-project/go-server/cg"
+	"github.com/code-game-project/go-server/cg/internal/apiclient"
+)
+
+type TestServer struct {
+	// The base URL of the test server, e.g. http://127.0.0.1:1234.
+	URL string
+
+	// The in-process server (nil for servers created with NewRemoteServer).
+	Server *cg.Server
+
+	httpServer *httptest.Server
+
+	recorder *sessionRecorder
+}
+
+// NewTestServer starts a new CodeGame server on a random local port.
+// runGameFunc is called for every created game like with cg.Server.Run.
+func NewTestServer(config cg.ServerConfig, runGameFunc func(game *cg.Game, config json.RawMessage)) *TestServer {
+	name := config.Name
+	if name == "" {
+		name = "test"
+	}
+	server := cg.NewServer(name, config)
+	httpServer := httptest.NewServer(server.Handler(runGameFunc))
+	server.StartAutostartGames()
+	return &TestServer{
+		URL:        httpServer.URL,
+		Server:     server,
+		httpServer: httpServer,
+	}
+}
+
+// NewRemoteServer returns a TestServer for an already running CodeGame server at url, e.g. http://localhost:8080.
+func NewRemoteServer(url string) *TestServer {
+	return &TestServer{
+		URL: strings.TrimSuffix(url, "/"),
+	}
+}
+
+// Close shuts down the test server, closing all games and waiting up to DefaultTimeout until their game functions have returned.
+// It does nothing for remote servers.
+func (s *TestServer) Close() {
+	if s.Server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		s.Server.Shutdown(ctx)
+		cancel()
+	}
+	if s.httpServer != nil {
+		s.httpServer.Close()
+	}
+}
+
+// CreateGame creates a new game with the given config and returns its ID and join secret.
+func (s *TestServer) CreateGame(public, protected bool, config any) (string, string, error) {
+	gameID, joinSecret, err := apiclient.CreateGame(s.URL, public, protected, config)
+	if err == nil {
+		s.recordCreateGame(gameID, public, protected, config)
+	}
+	return gameID, joinSecret, err
+}
+
+// Join creates a new player in the game and connects a socket to it.
+func (s *TestServer) Join(gameID, username, joinSecret string) (*TestClient, error) {
+	playerID, playerSecret, err := apiclient.CreatePlayer(s.URL, gameID, username, joinSecret)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.connect(gameID, playerID, playerSecret)
+	if err != nil {
+		return nil, err
+	}
+	s.recordJoin(client, username, joinSecret)
+	return client, nil
+}
+
+// Connect connects a new socket to an existing player.
+func (s *TestServer) Connect(gameID, playerID, playerSecret string) (*TestClient, error) {
+	client, err := s.connect(gameID, playerID, playerSecret)
+	if err != nil {
+		return nil, err
+	}
+	s.recordConnect(client)
+	return client, nil
+}
+
+func (s *TestServer) connect(gameID, playerID, playerSecret string) (*TestClient, error) {
+	client, err := s.dial(apiclient.ConnectURL(s.URL, gameID, playerID, playerSecret))
+	if err != nil {
+		return nil, err
+	}
+	client.GameID = gameID
+	client.PlayerID = playerID
+	client.PlayerSecret = playerSecret
+	return client, nil
+}
+
+// Spectate connects a new spectator socket to the game.
+func (s *TestServer) Spectate(gameID string) (*TestClient, error) {
+	client, err := s.dial(apiclient.SpectateURL(s.URL, gameID))
+	if err != nil {
+		return nil, err
+	}
+	client.GameID = gameID
+	s.recordSpectate(client)
+	return client, nil
+}
+
+// CloseGame closes the game with the admin API of the server.
+func (s *TestServer) CloseGame(gameID, adminToken string) error {
+	return apiclient.CloseGame(s.URL, gameID, adminToken)
+}
@@ -0,0 +1,390 @@
+package cgtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/code-game-project/go-server/cg"
+)
+
+// UpdateGolden makes ReplaySession overwrite golden files instead of comparing against them.
+// It is enabled by setting the CGTEST_UPDATE environment variable to a non-empty value.
+var UpdateGolden = os.Getenv("CGTEST_UPDATE") != ""
+
+// The time without new events after which ReplaySession considers a client to be done receiving events.
+var SettleTime = 100 * time.Millisecond
+
+type SessionAction string
+
+const (
+	ActionCreateGame SessionAction = "create_game"
+	ActionJoin       SessionAction = "join"
+	ActionConnect    SessionAction = "connect"
+	ActionSpectate   SessionAction = "spectate"
+	ActionSend       SessionAction = "send"
+	ActionDisconnect SessionAction = "disconnect"
+)
+
+// Session is a recorded sequence of client interactions with a test server.
+type Session struct {
+	Steps []SessionStep `json:"steps"`
+}
+
+type SessionStep struct {
+	Action SessionAction `json:"action"`
+	// The time since the previous step in milliseconds.
+	DelayMS int64 `json:"delay_ms,omitempty"`
+	// The alias of the client which performs the step.
+	Client string `json:"client,omitempty"`
+	// The index of the game in the order of creation.
+	Game int `json:"game"`
+
+	Public    bool            `json:"public,omitempty"`
+	Protected bool            `json:"protected,omitempty"`
+	Config    json.RawMessage `json:"config,omitempty"`
+	Username  string          `json:"username,omitempty"`
+	Command   cg.CommandName  `json:"command,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+type sessionRecorder struct {
+	lock       sync.Mutex
+	session    Session
+	last       time.Time
+	games      map[string]int
+	aliases    map[string]string
+	spectators int
+}
+
+// Record starts recording all interactions of clients created by the test server.
+func (s *TestServer) Record() {
+	s.recorder = &sessionRecorder{
+		last:    time.Now(),
+		games:   make(map[string]int),
+		aliases: make(map[string]string),
+	}
+}
+
+// Recording returns the interactions recorded since the last call to Record.
+func (s *TestServer) Recording() Session {
+	if s.recorder == nil {
+		return Session{}
+	}
+	s.recorder.lock.Lock()
+	defer s.recorder.lock.Unlock()
+	steps := make([]SessionStep, len(s.recorder.session.Steps))
+	copy(steps, s.recorder.session.Steps)
+	return Session{Steps: steps}
+}
+
+// LoadSession reads a session from a JSON file.
+func LoadSession(path string) (Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Session{}, err
+	}
+	var session Session
+	err = json.Unmarshal(data, &session)
+	return session, err
+}
+
+// Save writes the session to a JSON file.
+func (s Session) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func (s *TestServer) record(step SessionStep) {
+	r := s.recorder
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	now := time.Now()
+	step.DelayMS = now.Sub(r.last).Milliseconds()
+	r.last = now
+	r.session.Steps = append(r.session.Steps, step)
+}
+
+func (s *TestServer) recordCreateGame(gameID string, public, protected bool, config any) {
+	if s.recorder == nil {
+		return
+	}
+	var data json.RawMessage
+	if config != nil {
+		data, _ = json.Marshal(config)
+	}
+	s.recorder.lock.Lock()
+	index := len(s.recorder.games)
+	s.recorder.games[gameID] = index
+	s.recorder.lock.Unlock()
+	s.record(SessionStep{
+		Action:    ActionCreateGame,
+		Game:      index,
+		Public:    public,
+		Protected: protected,
+		Config:    data,
+	})
+}
+
+func (s *TestServer) recordJoin(client *TestClient, username, joinSecret string) {
+	if s.recorder == nil {
+		return
+	}
+	s.recorder.lock.Lock()
+	client.alias = username
+	s.recorder.aliases[client.PlayerID] = username
+	game := s.recorder.games[client.GameID]
+	s.recorder.lock.Unlock()
+	s.record(SessionStep{
+		Action:   ActionJoin,
+		Client:   client.alias,
+		Game:     game,
+		Username: username,
+	})
+}
+
+func (s *TestServer) recordConnect(client *TestClient) {
+	if s.recorder == nil {
+		return
+	}
+	s.recorder.lock.Lock()
+	client.alias = s.recorder.aliases[client.PlayerID]
+	game := s.recorder.games[client.GameID]
+	s.recorder.lock.Unlock()
+	s.record(SessionStep{
+		Action: ActionConnect,
+		Client: client.alias,
+		Game:   game,
+	})
+}
+
+func (s *TestServer) recordSpectate(client *TestClient) {
+	if s.recorder == nil {
+		return
+	}
+	s.recorder.lock.Lock()
+	s.recorder.spectators++
+	client.alias = fmt.Sprintf("spectator-%d", s.recorder.spectators)
+	game := s.recorder.games[client.GameID]
+	s.recorder.lock.Unlock()
+	s.record(SessionStep{
+		Action: ActionSpectate,
+		Client: client.alias,
+		Game:   game,
+	})
+}
+
+func (s *TestServer) recordSend(client *TestClient, cmd cg.CommandName, data json.RawMessage) {
+	s.record(SessionStep{
+		Action:  ActionSend,
+		Client:  client.alias,
+		Command: cmd,
+		Data:    data,
+	})
+}
+
+func (s *TestServer) recordDisconnect(client *TestClient) {
+	s.record(SessionStep{
+		Action: ActionDisconnect,
+		Client: client.alias,
+	})
+}
+
+type replayedEvent struct {
+	Name cg.EventName    `json:"name"`
+	Data json.RawMessage `json:"data"`
+}
+
+type replay struct {
+	server  *TestServer
+	games   []string
+	secrets []string
+	clients map[string]*TestClient
+	players map[string]*TestClient
+	events  map[string][]replayedEvent
+}
+
+// ReplaySession replays the steps of session against server and compares the events received by each client
+// with the golden file at goldenPath. IDs and secrets in event data are replaced with stable placeholders.
+// Run the test with CGTEST_UPDATE=1 to create or update the golden file.
+func ReplaySession(t testing.TB, server *TestServer, session Session, goldenPath string) {
+	t.Helper()
+
+	r := &replay{
+		server:  server,
+		clients: make(map[string]*TestClient),
+		players: make(map[string]*TestClient),
+		events:  make(map[string][]replayedEvent),
+	}
+	defer func() {
+		for _, c := range r.clients {
+			c.conn.Close()
+		}
+	}()
+
+	for i, step := range session.Steps {
+		time.Sleep(time.Duration(step.DelayMS) * time.Millisecond)
+		if err := r.run(step); err != nil {
+			t.Fatalf("step %d (%s): %s", i, step.Action, err)
+		}
+		r.collect(0)
+	}
+	r.collect(SettleTime)
+
+	transcript, err := json.MarshalIndent(r.events, "", "  ")
+	if err != nil {
+		t.Fatalf("failed to encode transcript: %s", err)
+	}
+	transcript = append(r.normalize(transcript), '\n')
+
+	if UpdateGolden {
+		err = os.WriteFile(goldenPath, transcript, 0o644)
+		if err != nil {
+			t.Fatalf("failed to write golden file: %s", err)
+		}
+		return
+	}
+
+	golden, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with CGTEST_UPDATE=1 to create it): %s", err)
+	}
+	if string(golden) != string(transcript) {
+		t.Errorf("received events differ from %s:\n%s", goldenPath, diffLines(string(golden), string(transcript)))
+	}
+}
+
+func (r *replay) run(step SessionStep) error {
+	switch step.Action {
+	case ActionCreateGame:
+		var config any
+		if len(step.Config) > 0 {
+			config = step.Config
+		}
+		id, secret, err := r.server.CreateGame(step.Public, step.Protected, config)
+		if err != nil {
+			return err
+		}
+		r.games = append(r.games, id)
+		r.secrets = append(r.secrets, secret)
+	case ActionJoin:
+		if step.Game >= len(r.games) {
+			return fmt.Errorf("unknown game %d", step.Game)
+		}
+		client, err := r.server.Join(r.games[step.Game], step.Username, r.secrets[step.Game])
+		if err != nil {
+			return err
+		}
+		r.clients[step.Client] = client
+		r.players[step.Client] = client
+	case ActionConnect:
+		player, ok := r.players[step.Client]
+		if !ok {
+			return fmt.Errorf("unknown client '%s'", step.Client)
+		}
+		client, err := r.server.Connect(player.GameID, player.PlayerID, player.PlayerSecret)
+		if err != nil {
+			return err
+		}
+		r.clients[step.Client] = client
+	case ActionSpectate:
+		if step.Game >= len(r.games) {
+			return fmt.Errorf("unknown game %d", step.Game)
+		}
+		client, err := r.server.Spectate(r.games[step.Game])
+		if err != nil {
+			return err
+		}
+		r.clients[step.Client] = client
+	case ActionSend:
+		client, ok := r.clients[step.Client]
+		if !ok {
+			return fmt.Errorf("unknown client '%s'", step.Client)
+		}
+		return client.Send(step.Command, step.Data)
+	case ActionDisconnect:
+		client, ok := r.clients[step.Client]
+		if !ok {
+			return fmt.Errorf("unknown client '%s'", step.Client)
+		}
+		r.collectClient(step.Client, client, SettleTime)
+		delete(r.clients, step.Client)
+		return client.Close()
+	default:
+		return fmt.Errorf("unknown action '%s'", step.Action)
+	}
+	return nil
+}
+
+// collect receives events of all clients until none of them has received an event for timeout.
+func (r *replay) collect(timeout time.Duration) {
+	for alias, client := range r.clients {
+		r.collectClient(alias, client, timeout)
+	}
+}
+
+func (r *replay) collectClient(alias string, client *TestClient, timeout time.Duration) {
+	for {
+		event, err := client.NextEvent(timeout)
+		if err != nil {
+			return
+		}
+		r.events[alias] = append(r.events[alias], replayedEvent{
+			Name: event.Name,
+			Data: event.Data,
+		})
+	}
+}
+
+// normalize replaces all IDs and secrets in the transcript with placeholders.
+func (r *replay) normalize(transcript []byte) []byte {
+	replacements := make([]string, 0)
+	for i, id := range r.games {
+		replacements = append(replacements, id, fmt.Sprintf("<game-%d>", i))
+		if r.secrets[i] != "" {
+			replacements = append(replacements, r.secrets[i], fmt.Sprintf("<join-secret-%d>", i))
+		}
+	}
+
+	aliases := make([]string, 0, len(r.players))
+	for alias := range r.players {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		p := r.players[alias]
+		replacements = append(replacements, p.PlayerID, fmt.Sprintf("<player:%s>", alias), p.PlayerSecret, fmt.Sprintf("<player-secret:%s>", alias))
+	}
+
+	return []byte(strings.NewReplacer(replacements...).Replace(string(transcript)))
+}
+
+func diffLines(expected, actual string) string {
+	exp := strings.Split(expected, "\n")
+	act := strings.Split(actual, "\n")
+	var b strings.Builder
+	for i := 0; i < len(exp) || i < len(act); i++ {
+		var e, a string
+		if i < len(exp) {
+			e = exp[i]
+		}
+		if i < len(act) {
+			a = act[i]
+		}
+		if e != a {
+			fmt.Fprintf(&b, "line %d:\n- %s\n+ %s\n", i+1, e, a)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,130 @@
+package cg
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// CommandClosingAck is sent by players after they have sent their final commands in response to cg_closing.
+	CommandClosingAck CommandName = "cg_closing_ack"
+
+	// EventClosing announces that the game will be closed at the deadline.
+	EventClosing EventName = "cg_closing"
+	// EventClosed contains the final results of the game and is the last event before the sockets are closed.
+	EventClosed EventName = "cg_closed"
+)
+
+type ClosingEventData struct {
+	// The time at which the game will be closed in unix milliseconds.
+	Deadline int64 `json:"deadline"`
+}
+
+type ClosedEventData struct {
+	Results any `json:"results,omitempty"`
+}
+
+type closingState struct {
+	lock     sync.Mutex
+	acks     map[string]struct{}
+	timer    Timer
+	results  func() any
+	finished bool
+}
+
+// CloseGracefully announces the end of the game with a cg_closing event.
+// Players may send their final commands until all connected players acknowledged with cg_closing_ack or timeout elapsed.
+// Afterwards the value returned by results (nil => no results) is sent with the cg_closed event and the game is closed.
+// Both happen on the goroutine consuming the command queue, so the game loop must keep handling commands.
+func (g *Game) CloseGracefully(timeout time.Duration, results func() any) {
+	state := &closingState{
+		acks:    make(map[string]struct{}),
+		results: results,
+	}
+
+	g.closingLock.Lock()
+	if g.closingState != nil {
+		g.closingLock.Unlock()
+		return
+	}
+	g.closingState = state
+	g.closingLock.Unlock()
+
+	clock := g.server.config.Clock
+	g.Log.Info("Closing the game in %s...", timeout)
+	g.SendClosing(clock.Now().Add(timeout))
+
+	state.lock.Lock()
+	state.timer = clock.AfterFunc(timeout, g.finishClosing)
+	state.lock.Unlock()
+
+	if g.allClosingAcks(state) {
+		g.finishClosing()
+	}
+}
+
+func (g *Game) handleClosingAck(player *Player) error {
+	g.closingLock.Lock()
+	state := g.closingState
+	g.closingLock.Unlock()
+	if state == nil {
+		return nil
+	}
+
+	state.lock.Lock()
+	state.acks[player.ID] = struct{}{}
+	state.lock.Unlock()
+
+	if g.allClosingAcks(state) {
+		g.finishClosing()
+	}
+	return nil
+}
+
+// allClosingAcks returns true if all players with connected sockets acknowledged the closing.
+func (g *Game) allClosingAcks(state *closingState) bool {
+	g.playersLock.RLock()
+	defer g.playersLock.RUnlock()
+	state.lock.Lock()
+	defer state.lock.Unlock()
+	for id, p := range g.players {
+		if _, ok := state.acks[id]; !ok && p.SocketCount() > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *Game) finishClosing() {
+	g.closingLock.Lock()
+	state := g.closingState
+	g.closingLock.Unlock()
+
+	state.lock.Lock()
+	if state.finished {
+		state.lock.Unlock()
+		return
+	}
+	state.finished = true
+	if state.timer != nil {
+		state.timer.Stop()
+	}
+	state.lock.Unlock()
+
+	ok := g.enqueue(CommandWrapper{
+		scheduled: func() {
+			var results any
+			if state.results != nil {
+				results = state.results()
+			}
+			g.SendClosed(results)
+			g.closingLock.Lock()
+			g.results = results
+			g.closingLock.Unlock()
+			g.CloseWithReason("game finished")
+		},
+	})
+	if !ok {
+		g.CloseWithReason("game finished")
+	}
+}
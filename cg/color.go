@@ -0,0 +1,36 @@
+package cg
+
+// DefaultPlayerColors is the palette used when ServerConfig.PlayerColors is empty.
+var DefaultPlayerColors = []string{
+	"#e6194b", "#3cb44b", "#4363d8", "#f58231", "#911eb4", "#42d4f4", "#f032e6", "#bfef45",
+}
+
+// nextColorIndex returns the lowest palette index not used by a player of the game.
+// If all colors are taken, indices beyond the palette are used, which wrap around in Player.Color.
+// The caller must hold playersLock.
+func (g *Game) nextColorIndex() int {
+	used := make(map[int]struct{}, len(g.players))
+	for _, p := range g.players {
+		used[p.colorIndex] = struct{}{}
+	}
+	index := 0
+	for {
+		if _, ok := used[index]; !ok {
+			return index
+		}
+		index++
+	}
+}
+
+// ColorIndex returns the index of the color or avatar assigned to the player when joining.
+// Players of a game have distinct indices, which frontends can use to pick an avatar.
+func (p *Player) ColorIndex() int {
+	return p.colorIndex
+}
+
+// Color returns the color of the player from ServerConfig.PlayerColors.
+// Colors repeat if a game has more players than the palette has colors.
+func (p *Player) Color() string {
+	palette := p.server.config.PlayerColors
+	return palette[p.colorIndex%len(palette)]
+}
@@ -0,0 +1,354 @@
+/*
+Package conformance runs a standard battery of CodeGame protocol checks against a running server,
+so that client library authors and server forks can verify their compatibility.
+
+	results := conformance.Run(conformance.Config{URL: "http://localhost:8080"})
+*/
+package conformance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/code-game-project/go-server/cg"
+	"github.com/code-game-project/go-server/cg/cgtest"
+)
+
+type Config struct {
+	// The base URL of the server, e.g. http://localhost:8080.
+	URL string
+	// The config of the games created by the checks.
+	GameConfig any
+	// A command which makes the game send an event to all players, e.g. any command for cg.EchoGame.
+	// The missed events check is skipped if it is empty.
+	TriggerCommand cg.CommandName
+	// The data of TriggerCommand.
+	TriggerData any
+	// The time to wait for an expected event. (default: 5 seconds)
+	Timeout time.Duration
+	// The admin token of the server, which is used to close the games created by the checks. (empty => games are left open)
+	AdminToken string
+}
+
+type Result struct {
+	Name string
+	// The check could not run with the given config.
+	Skipped bool
+	// The reason the check failed (nil => passed or skipped).
+	Err error
+}
+
+var errSkipped = errors.New("skipped")
+
+type check struct {
+	name string
+	run  func(c *checker) error
+}
+
+var checks = []check{
+	{"info", (*checker).checkInfo},
+	{"create_game", (*checker).checkCreateGame},
+	{"join_connect", (*checker).checkJoinConnect},
+	{"connect_wrong_secret", (*checker).checkConnectWrongSecret},
+	{"spectate", (*checker).checkSpectate},
+	{"spectate_missing_game", (*checker).checkSpectateMissingGame},
+	{"reconnect", (*checker).checkReconnect},
+	{"missed_events", (*checker).checkMissedEvents},
+	{"error_decode_failed", (*checker).checkErrorDecodeFailed},
+	{"error_spectator_command", (*checker).checkErrorSpectatorCommand},
+}
+
+// TB is the part of testing.TB used by Test.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+	Logf(format string, args ...any)
+	Cleanup(f func())
+}
+
+type checker struct {
+	config Config
+	server *cgtest.TestServer
+	// the IDs of the created games
+	games []string
+}
+
+// Run runs all checks against the server and returns their results in order.
+// The created games are closed afterwards if AdminToken is set.
+func Run(config Config) []Result {
+	c := newChecker(config)
+	defer c.closeGames()
+	return c.run()
+}
+
+// Test runs all checks against the server and reports every failed check as an error of t.
+// The created games are closed in a cleanup function of t if AdminToken is set.
+func Test(t TB, config Config) {
+	t.Helper()
+	c := newChecker(config)
+	t.Cleanup(c.closeGames)
+	for _, result := range c.run() {
+		if result.Skipped {
+			t.Logf("%s: skipped", result.Name)
+		} else if result.Err != nil {
+			t.Errorf("%s: %s", result.Name, result.Err)
+		}
+	}
+}
+
+func newChecker(config Config) *checker {
+	if config.Timeout == 0 {
+		config.Timeout = 5 * time.Second
+	}
+	return &checker{
+		config: config,
+		server: cgtest.NewRemoteServer(config.URL),
+	}
+}
+
+func (c *checker) run() []Result {
+	results := make([]Result, 0, len(checks))
+	for _, ch := range checks {
+		err := ch.run(c)
+		result := Result{Name: ch.name}
+		if errors.Is(err, errSkipped) {
+			result.Skipped = true
+		} else {
+			result.Err = err
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func (c *checker) createGame() (string, error) {
+	id, _, err := c.server.CreateGame(false, false, c.config.GameConfig)
+	if err != nil {
+		return "", err
+	}
+	if id == "" {
+		return "", errors.New("empty game ID")
+	}
+	c.games = append(c.games, id)
+	return id, nil
+}
+
+func (c *checker) closeGames() {
+	if c.config.AdminToken == "" {
+		return
+	}
+	for _, id := range c.games {
+		c.server.CloseGame(id, c.config.AdminToken)
+	}
+	c.games = nil
+}
+
+func (c *checker) checkInfo() error {
+	resp, err := http.Get(c.server.URL + "/api/info")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET /api/info: %s", resp.Status)
+	}
+	var info struct {
+		Name      string `json:"name"`
+		CGVersion string `json:"cg_version"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	if err != nil {
+		return fmt.Errorf("decode /api/info: %w", err)
+	}
+	if info.Name == "" {
+		return errors.New("missing name in /api/info")
+	}
+	if !cg.IsCompatible(info.CGVersion) {
+		return fmt.Errorf("CodeGame version %s is not compatible with %s", info.CGVersion, cg.CGVersion)
+	}
+	return nil
+}
+
+func (c *checker) checkCreateGame() error {
+	id, err := c.createGame()
+	if err != nil {
+		return err
+	}
+	resp, err := http.Get(c.server.URL + "/api/games/" + id)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET /api/games/%s: %s", id, resp.Status)
+	}
+	return nil
+}
+
+func (c *checker) checkJoinConnect() error {
+	id, err := c.createGame()
+	if err != nil {
+		return err
+	}
+	client, err := c.server.Join(id, "conformance", "")
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if client.PlayerID == "" || client.PlayerSecret == "" {
+		return errors.New("missing player credentials")
+	}
+	return nil
+}
+
+func (c *checker) checkConnectWrongSecret() error {
+	id, err := c.createGame()
+	if err != nil {
+		return err
+	}
+	client, err := c.server.Join(id, "conformance", "")
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	other, err := c.server.Connect(id, client.PlayerID, "wrong"+client.PlayerSecret)
+	if err == nil {
+		other.Close()
+		return errors.New("connected with a wrong player secret")
+	}
+	return nil
+}
+
+func (c *checker) checkSpectate() error {
+	id, err := c.createGame()
+	if err != nil {
+		return err
+	}
+	client, err := c.server.Spectate(id)
+	if err != nil {
+		return err
+	}
+	return client.Close()
+}
+
+func (c *checker) checkSpectateMissingGame() error {
+	client, err := c.server.Spectate("00000000-0000-0000-0000-000000000000")
+	if err == nil {
+		client.Close()
+		return errors.New("spectated a game which does not exist")
+	}
+	return nil
+}
+
+func (c *checker) checkReconnect() error {
+	id, err := c.createGame()
+	if err != nil {
+		return err
+	}
+	client, err := c.server.Join(id, "conformance", "")
+	if err != nil {
+		return err
+	}
+	client.Close()
+
+	reconnected, err := c.server.Connect(id, client.PlayerID, client.PlayerSecret)
+	if err != nil {
+		return fmt.Errorf("reconnect: %w", err)
+	}
+	return reconnected.Close()
+}
+
+func (c *checker) checkMissedEvents() error {
+	if c.config.TriggerCommand == "" {
+		return errSkipped
+	}
+
+	id, err := c.createGame()
+	if err != nil {
+		return err
+	}
+	absent, err := c.server.Join(id, "absent", "")
+	if err != nil {
+		return err
+	}
+	// Close returns after the server has removed the socket, so the player misses the triggered event
+	absent.Close()
+
+	present, err := c.server.Join(id, "present", "")
+	if err != nil {
+		return err
+	}
+	defer present.Close()
+	err = present.Send(c.config.TriggerCommand, c.config.TriggerData)
+	if err != nil {
+		return err
+	}
+	event, err := present.NextEvent(c.config.Timeout)
+	if err != nil {
+		return fmt.Errorf("trigger command: %w", err)
+	}
+
+	reconnected, err := c.server.Connect(id, absent.PlayerID, absent.PlayerSecret)
+	if err != nil {
+		return fmt.Errorf("reconnect: %w", err)
+	}
+	defer reconnected.Close()
+	_, err = reconnected.WaitForEvent(event.Name, c.config.Timeout)
+	if err != nil {
+		return fmt.Errorf("missed event: %w", err)
+	}
+	return nil
+}
+
+func (c *checker) checkErrorDecodeFailed() error {
+	id, err := c.createGame()
+	if err != nil {
+		return err
+	}
+	client, err := c.server.Join(id, "conformance", "")
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	err = client.SendRaw([]byte("{not json"))
+	if err != nil {
+		return err
+	}
+	return expectError(client, cg.ErrorDecodeFailed, c.config.Timeout)
+}
+
+func (c *checker) checkErrorSpectatorCommand() error {
+	id, err := c.createGame()
+	if err != nil {
+		return err
+	}
+	client, err := c.server.Spectate(id)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	err = client.Send("conformance", nil)
+	if err != nil {
+		return err
+	}
+	return expectError(client, cg.ErrorUnexpectedCommand, c.config.Timeout)
+}
+
+func expectError(client *cgtest.TestClient, code cg.ErrorCode, timeout time.Duration) error {
+	event, err := client.WaitForEvent(cg.EventError, timeout)
+	if err != nil {
+		return err
+	}
+	var data cg.ErrorEventData
+	err = json.Unmarshal(event.Data, &data)
+	if err != nil {
+		return fmt.Errorf("decode '%s' event: %w", cg.EventError, err)
+	}
+	if data.Code != code {
+		return fmt.Errorf("expected error code '%s', got '%s'", code, data.Code)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package cg
+
+import (
+	"sync"
+	"time"
+)
+
+// The time span in which ping failures and reconnects are counted for ConnectionQuality.
+const connectionQualityWindow = 5 * time.Minute
+
+// ConnectionQuality describes the recent connection of a player.
+type ConnectionQuality struct {
+	// The average round-trip time of the pings to the websockets of the player. (0 => not measured yet or only TCP sockets)
+	Latency time.Duration
+	// The number of pings which have not been answered within PongTimeout in the last 5 minutes.
+	PingFailures int
+	// The number of times the player connected a new socket after its first one in the last 5 minutes.
+	Reconnects int
+}
+
+type connectionQuality struct {
+	lock         sync.Mutex
+	latency      time.Duration
+	pingFailures []time.Time
+	reconnects   []time.Time
+}
+
+// ConnectionQuality returns the recent connection quality of the player,
+// e.g. to adapt the tick rate or pause the game for a lagging player.
+func (p *Player) ConnectionQuality() ConnectionQuality {
+	now := p.server.config.Clock.Now()
+	q := &p.quality
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.pingFailures = trimBefore(q.pingFailures, now.Add(-connectionQualityWindow))
+	q.reconnects = trimBefore(q.reconnects, now.Add(-connectionQualityWindow))
+	return ConnectionQuality{
+		Latency:      q.latency,
+		PingFailures: len(q.pingFailures),
+		Reconnects:   len(q.reconnects),
+	}
+}
+
+// addLatency adds a measured round-trip time to the moving average.
+func (q *connectionQuality) addLatency(rtt time.Duration) {
+	q.lock.Lock()
+	if q.latency == 0 {
+		q.latency = rtt
+	} else {
+		q.latency = (q.latency*7 + rtt) / 8
+	}
+	q.lock.Unlock()
+}
+
+func (q *connectionQuality) addPingFailure(now time.Time) {
+	q.lock.Lock()
+	q.pingFailures = append(trimBefore(q.pingFailures, now.Add(-connectionQualityWindow)), now)
+	q.lock.Unlock()
+}
+
+func (q *connectionQuality) addReconnect(now time.Time) {
+	q.lock.Lock()
+	q.reconnects = append(trimBefore(q.reconnects, now.Add(-connectionQualityWindow)), now)
+	q.lock.Unlock()
+}
+
+// trimBefore removes the times before t from the sorted slice times.
+func trimBefore(times []time.Time, t time.Time) []time.Time {
+	i := 0
+	for i < len(times) && times[i].Before(t) {
+		i++
+	}
+	return times[i:]
+}
+
+// reportConnectionQuality calls OnConnectionQuality for every player every ConnectionQualityInterval
+// on the goroutine consuming the command queue until the game is closed.
+func (g *Game) reportConnectionQuality() {
+	ticker := g.server.config.Clock.NewTicker(g.server.config.ConnectionQualityInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C():
+			if g.OnConnectionQuality == nil {
+				continue
+			}
+			g.enqueue(CommandWrapper{
+				scheduled: func() {
+					g.ForEachPlayer(func(player *Player) {
+						g.OnConnectionQuality(player, player.ConnectionQuality())
+					})
+				},
+			})
+		case <-g.closing:
+			return
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package cg
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/rs/cors"
+)
+
+// CORSConfig restricts which websites may use the HTTP API and open websocket connections.
+type CORSConfig struct {
+	// The origins allowed to access the server, e.g. "https://example.com" or "https://*.example.com". (empty => all origins)
+	AllowedOrigins []string
+	// The headers clients may use in cross-origin requests. (empty => all headers)
+	AllowedHeaders []string
+	// Allow cross-origin requests to include credentials like cookies.
+	AllowCredentials bool
+}
+
+func (s *Server) corsHandler(handler http.Handler) http.Handler {
+	origins := s.config.CORS.AllowedOrigins
+	if len(origins) == 0 {
+		origins = []string{"*"}
+	}
+	headers := s.config.CORS.AllowedHeaders
+	if len(headers) == 0 {
+		headers = []string{"*"}
+	}
+	return cors.New(cors.Options{
+		AllowedOrigins:   origins,
+		AllowedHeaders:   headers,
+		AllowedMethods:   []string{"GET", "HEAD", "POST", "PUT", "DELETE", "CONNECT", "OPTIONS", "TRACE", "PATCH"},
+		AllowCredentials: s.config.CORS.AllowCredentials,
+	}).Handler(handler)
+}
+
+// checkOrigin returns true if the websocket handshake r comes from an allowed origin.
+// Requests without an Origin header are not sent by browsers and always allowed.
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || len(s.config.CORS.AllowedOrigins) == 0 {
+		return true
+	}
+	for _, allowed := range s.config.CORS.AllowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+		if ok, _ := path.Match(allowed, origin); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,8 @@
 package cg
 
 import (
+	"path"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -13,7 +15,11 @@ type debugSocket struct {
 	conn   *websocket.Conn
 	done   chan struct{}
 
+	disconnectOnce sync.Once
+
 	severities map[DebugSeverity]bool
+	// name patterns like 'move' or 'cg_*' (empty => all messages)
+	names []string
 }
 
 type DebugSeverity string
@@ -25,14 +31,41 @@ const (
 	DebugTrace   = "trace"
 )
 
+// matchesName returns true if the socket should receive messages about the event or command name.
+// Messages without a name are only sent to sockets without name patterns.
+func (s *debugSocket) matchesName(name string) bool {
+	if len(s.names) == 0 {
+		return true
+	}
+	for _, pattern := range s.names {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// debugMessageName returns the name of the event or command contained in data.
+func debugMessageName(data any) string {
+	switch d := data.(type) {
+	case Event:
+		return string(d.Name)
+	case *Event:
+		return string(d.Name)
+	case Command:
+		return string(d.Name)
+	case *Command:
+		return string(d.Name)
+	}
+	return ""
+}
+
 func (s *debugSocket) send(message []byte) error {
 	s.conn.SetWriteDeadline(time.Now().Add(s.server.config.WebsocketTimeout))
 	return s.conn.WriteMessage(websocket.TextMessage, message)
 }
 
 func (s *debugSocket) handleConnection() {
-	s.done = make(chan struct{})
-
 	s.conn.SetReadDeadline(time.Now().Add(s.server.config.WebsocketTimeout))
 	s.conn.SetPongHandler(func(string) error {
 		s.conn.SetReadDeadline(time.Now().Add(s.server.config.WebsocketTimeout))
@@ -53,12 +86,12 @@ func (s *debugSocket) handleConnection() {
 }
 
 func (s *debugSocket) ping() {
-	ticker := time.NewTicker((s.server.config.WebsocketTimeout * 9) / 10)
+	ticker := s.server.config.Clock.NewTicker(s.server.config.PingInterval)
 	defer ticker.Stop()
 	for {
 		select {
-		case <-ticker.C:
-			s.conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(30*time.Second))
+		case <-ticker.C():
+			s.conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(s.server.config.PongTimeout))
 		case <-s.done:
 			return
 		}
@@ -66,7 +99,9 @@ func (s *debugSocket) ping() {
 }
 
 func (s *debugSocket) disconnect() {
-	close(s.done)
-	s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "disconnect"), time.Now().Add(5*time.Second))
-	s.conn.Close()
+	s.disconnectOnce.Do(func() {
+		close(s.done)
+		s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "disconnect"), time.Now().Add(5*time.Second))
+		s.conn.Close()
+	})
 }
@@ -0,0 +1,47 @@
+package cg
+
+import "encoding/json"
+
+// EchoGame is a runGameFunc for developing clients without a game.
+// Every command is broadcast back to all players and spectators as an event with the same name and data.
+// Joining, leaving and connecting players and spectators are logged to the game logger.
+//
+//	server.Run(cg.EchoGame)
+func EchoGame(game *Game, config json.RawMessage) {
+	game.SetConfig(config)
+	game.Log.InfoData(config, "Echo game %s created.", game.ID)
+
+	game.OnPlayerJoined = func(player *Player) {
+		game.Log.Info("Player %s (%s) joined.", player.ID, player.username())
+	}
+	game.OnPlayerLeft = func(player *Player) {
+		game.Log.Info("Player %s (%s) left.", player.ID, player.username())
+	}
+	game.OnPlayerSocketConnected = func(player *Player, socket *GameSocket) {
+		game.Log.Info("Socket %s of player %s (%s) connected.", socket.ID, player.ID, player.username())
+	}
+	game.OnPlayerDisconnected = func(player *Player) {
+		game.Log.Info("Player %s (%s) disconnected.", player.ID, player.username())
+	}
+	game.OnSpectatorConnected = func(socket *GameSocket) {
+		game.Log.Info("Spectator %s connected.", socket.ID)
+	}
+
+	for {
+		wrapper, ok := game.WaitForNextCommand()
+		if !ok {
+			break
+		}
+		if wrapper.Origin != nil {
+			game.Log.InfoData(wrapper.Cmd, "Echoing '%s' command of player %s (%s).", wrapper.Cmd.Name, wrapper.Origin.ID, wrapper.Origin.username())
+		} else {
+			game.Log.InfoData(wrapper.Cmd, "Echoing '%s' command of the server.", wrapper.Cmd.Name)
+		}
+		err := game.Send(EventName(wrapper.Cmd.Name), wrapper.Cmd.Data)
+		if err != nil {
+			game.Log.Error("Failed to echo '%s' command: %s", wrapper.Cmd.Name, err)
+		}
+	}
+
+	game.Log.Info("Echo game %s closed.", game.ID)
+}
@@ -0,0 +1,96 @@
+package cg
+
+import (
+	"errors"
+)
+
+// EventError is sent to a socket whose command could not be handled.
+const EventError EventName = "cg_error"
+
+// ErrorCode is a machine-readable reason of an error event.
+type ErrorCode string
+
+const (
+	// The message could not be decoded as a command.
+	ErrorDecodeFailed ErrorCode = "decode_failed"
+	// The socket sent a command it may not send, e.g. a spectator sent a game command.
+	ErrorUnexpectedCommand ErrorCode = "unexpected_command"
+	// The command was rejected by Game.CommandValidator.
+	ErrorCommandRejected ErrorCode = "command_rejected"
+	// The command was sent too frequently.
+	ErrorRateLimited ErrorCode = "rate_limited"
+	// The command may only be sent by the host of the game.
+	ErrorNotHost ErrorCode = "not_host"
+	// The command may only be sent by the player whose turn it is.
+	ErrorNotYourTurn ErrorCode = "not_your_turn"
+	// The game has been closed.
+	ErrorGameClosed ErrorCode = "game_closed"
+)
+
+type ErrorEventData struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+	// The name of the command which caused the error.
+	Command CommandName `json:"command,omitempty"`
+}
+
+// Error is an error with a machine-readable code.
+// Return it from Game.CommandValidator to send its code to the client instead of command_rejected.
+type Error struct {
+	Code    ErrorCode
+	Message string
+}
+
+// NewError returns an error with a machine-readable code.
+func NewError(code ErrorCode, message string) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// errorCode returns the code of err or fallback if err is not an *Error.
+func errorCode(err error, fallback ErrorCode) ErrorCode {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return fallback
+}
+
+// SendError sends a cg_error event to all sockets of the player.
+func (p *Player) SendError(code ErrorCode, message string) error {
+	return p.Send(EventError, ErrorEventData{
+		Code:    code,
+		Message: message,
+	})
+}
+
+// SendError sends a cg_error event to the socket.
+func (s *GameSocket) SendError(code ErrorCode, message string) error {
+	return s.Send(EventError, ErrorEventData{
+		Code:    code,
+		Message: message,
+	})
+}
+
+func (s *GameSocket) sendCommandError(cmd Command, err error, fallback ErrorCode) {
+	s.Send(EventError, ErrorEventData{
+		Code:    errorCode(err, fallback),
+		Message: err.Error(),
+		Command: cmd.Name,
+	})
+}
+
+// sendCommandError sends a cg_error event about the command (empty => not about a command) to the room member.
+func (m *roomMember) sendCommandError(command CommandName, err error, fallback ErrorCode) {
+	m.send(EventError, ErrorEventData{
+		Code:    errorCode(err, fallback),
+		Message: err.Error(),
+		Command: command,
+	})
+}
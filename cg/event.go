@@ -6,9 +6,23 @@ import (
 
 type EventName string
 
+// EventResync is sent to reconnecting sockets with a snapshot of the game state provided by Game.OnResyncRequest
+// and to new spectators with the snapshot provided by Game.OnSpectatorNeedsSnapshot.
+const EventResync EventName = "cg_resync"
+
+// EventPlayerDisconnected is sent to all players and spectators when a player has lost all of its sockets
+// for longer than ReconnectGracePeriod.
+const EventPlayerDisconnected EventName = "cg_player_disconnected"
+
+type PlayerDisconnectedEventData struct {
+	Player string `json:"player"`
+}
+
 type Event struct {
 	Name EventName       `json:"name"`
 	Data json.RawMessage `json:"data"`
+	// The ID of the game which sent the event. It is only set for sockets spectating multiple games.
+	Game string `json:"game,omitempty"`
 }
 
 type CommandName string
@@ -21,6 +35,12 @@ type Command struct {
 type CommandWrapper struct {
 	Origin *Player
 	Cmd    Command
+	// The ID of the game which sent the command with SendToGame (empty => not sent by a game).
+	SourceGame string
+
+	scheduled func()
+	// the span of the command, see Tracer
+	span Span
 }
 
 // UnmarshalData decodes the command data into the struct pointed to by targetObjPtr.
@@ -0,0 +1,69 @@
+package cg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// The prefix of the standard events and commands defined by this package.
+const standardPrefix = "cg_"
+
+// ValidateEvents checks that the CGE file at EventsPath declares the game name of the server and that its events
+// and commands do not use the cg_ prefix of the standard events and commands and start with EventPrefix if one is configured.
+// A version differing from the game version is only logged as a warning.
+// It is called by Run, which exits if the validation fails.
+func (s *Server) ValidateEvents() error {
+	if s.config.EventsPath == "" {
+		return nil
+	}
+
+	source, err := os.ReadFile(s.config.EventsPath)
+	if err != nil {
+		return fmt.Errorf("read CGE file: %w", err)
+	}
+	file, err := parseCGE(string(source))
+	if err != nil {
+		return fmt.Errorf("parse CGE file: %w", err)
+	}
+
+	problems := make([]string, 0)
+	if file.Name != s.config.Name {
+		problems = append(problems, fmt.Sprintf("the declared name '%s' does not match the server name '%s'", file.Name, s.config.Name))
+	}
+	if file.Version != "" && s.config.Version != "" && strings.TrimPrefix(file.Version, "v") != s.config.Version {
+		s.log.Warning("The CGE file '%s' declares version %s but the game version is %s.", s.config.EventsPath, file.Version, s.config.Version)
+	}
+
+	for _, obj := range file.Objects {
+		if obj.Kind != "event" && obj.Kind != "command" {
+			continue
+		}
+		if strings.HasPrefix(obj.Name, standardPrefix) {
+			if isStandardName(obj.Kind, obj.Name) {
+				problems = append(problems, fmt.Sprintf("%s '%s' shadows the standard %s with the same name", obj.Kind, obj.Name, obj.Kind))
+			} else {
+				problems = append(problems, fmt.Sprintf("%s '%s' uses the reserved prefix '%s'", obj.Kind, obj.Name, standardPrefix))
+			}
+			continue
+		}
+		if s.config.EventPrefix != "" && !strings.HasPrefix(obj.Name, s.config.EventPrefix) {
+			problems = append(problems, fmt.Sprintf("%s '%s' does not start with the prefix '%s'", obj.Kind, obj.Name, s.config.EventPrefix))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid event names in '" + s.config.EventsPath + "':\n  " + strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+func isStandardName(kind, name string) bool {
+	for _, obj := range standardEvents {
+		if obj.Kind == kind && obj.Name == name {
+			return true
+		}
+	}
+	return false
+}
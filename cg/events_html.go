@@ -0,0 +1,112 @@
+package cg
+
+import (
+	"html/template"
+	"net/http"
+	"os"
+)
+
+var eventsTemplate = template.Must(template.New("events").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<title>{{.DisplayName}} – Events</title>
+<style>
+body { font-family: sans-serif; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; }
+section { margin-bottom: 2rem; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 0.25rem 0.5rem; text-align: left; }
+.doc { white-space: pre-line; color: #444; }
+code { background: #eee; padding: 0 0.2rem; }
+</style>
+</head>
+<body>
+<h1>{{.DisplayName}}</h1>
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+<p>Game version {{if .Version}}{{.Version}}{{else}}unknown{{end}}, CodeGame {{.CGVersion}}</p>
+{{define "objects"}}{{range .}}
+<section id="{{.Kind}}-{{.Name}}">
+<h3><code>{{.Name}}</code></h3>
+{{if .Doc}}<p class="doc">{{.Doc}}</p>{{end}}
+{{if .Fields}}<table>
+{{range .Fields}}<tr><td><code>{{.Name}}</code></td><td>{{if .Type}}<code>{{.Type}}</code>{{end}}</td><td class="doc">{{.Doc}}</td></tr>
+{{end}}</table>{{end}}
+</section>
+{{end}}{{end}}
+{{if .Commands}}<h2>Commands</h2>{{template "objects" .Commands}}{{end}}
+{{if .Events}}<h2>Events</h2>{{template "objects" .Events}}{{end}}
+{{if .Types}}<h2>Types</h2>{{template "objects" .Types}}{{end}}
+<h2>Standard commands</h2>{{template "objects" .StandardCommands}}
+<h2>Standard events</h2>{{template "objects" .StandardEvents}}
+</body>
+</html>
+`))
+
+type eventsTemplateData struct {
+	Lang             string
+	DisplayName      string
+	Description      string
+	Version          string
+	CGVersion        string
+	Commands         []cgeObject
+	Events           []cgeObject
+	Types            []cgeObject
+	StandardCommands []cgeObject
+	StandardEvents   []cgeObject
+}
+
+// eventsHTMLEndpoint renders the CGE file of the game and the standard events as a human-readable page.
+func (s *Server) eventsHTMLEndpoint(w http.ResponseWriter, r *http.Request) {
+	lang := r.URL.Query().Get("lang")
+	displayName, description := s.localizedInfo(lang)
+	if displayName == "" {
+		displayName = s.config.Name
+	}
+
+	data := eventsTemplateData{
+		Lang:        lang,
+		DisplayName: displayName,
+		Description: description,
+		Version:     s.config.Version,
+		CGVersion:   CGVersion,
+	}
+
+	if s.config.EventsPath != "" {
+		source, err := os.ReadFile(s.config.EventsPath)
+		if err != nil {
+			s.log.Error("Couldn't read '%s': %s", s.config.EventsPath, err)
+			send(w, http.StatusInternalServerError, "failed to read CGE file")
+			return
+		}
+		file, err := parseCGE(string(source))
+		if err != nil {
+			s.log.Error("Couldn't parse '%s': %s", s.config.EventsPath, err)
+			send(w, http.StatusInternalServerError, "failed to parse CGE file")
+			return
+		}
+		for _, obj := range file.Objects {
+			switch obj.Kind {
+			case "command":
+				data.Commands = append(data.Commands, obj)
+			case "event":
+				data.Events = append(data.Events, obj)
+			default:
+				data.Types = append(data.Types, obj)
+			}
+		}
+	}
+
+	for _, obj := range standardEvents {
+		if obj.Kind == "command" {
+			data.StandardCommands = append(data.StandardCommands, obj)
+		} else {
+			data.StandardEvents = append(data.StandardEvents, obj)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := eventsTemplate.Execute(w, data)
+	if err != nil {
+		s.log.Error("Failed to render events page: %s", err)
+	}
+}
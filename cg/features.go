@@ -0,0 +1,24 @@
+package cg
+
+// SetFeatures sets the optional mechanics enabled in the game, e.g. {"chat": true, "spectator_delay": false},
+// which are included in /api/games/{gameId} for clients to detect them per game.
+func (g *Game) SetFeatures(features map[string]bool) {
+	copied := make(map[string]bool, len(features))
+	for name, enabled := range features {
+		copied[name] = enabled
+	}
+	g.configLock.Lock()
+	g.features = copied
+	g.configLock.Unlock()
+}
+
+// Features returns a copy of the features set with SetFeatures.
+func (g *Game) Features() map[string]bool {
+	g.configLock.RLock()
+	defer g.configLock.RUnlock()
+	features := make(map[string]bool, len(g.features))
+	for name, enabled := range g.features {
+		features[name] = enabled
+	}
+	return features
+}
@@ -0,0 +1,260 @@
+package cg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLogMaxSize    = 10 << 20
+	defaultLogMaxBackups = 3
+)
+
+// logFile is the log file of a game shared by the loggers of the game and its players.
+// When a line would exceed maxSize, the file is renamed to <name>.1 (the existing backups are shifted)
+// and a new file is started.
+type logFile struct {
+	lock       sync.Mutex
+	path       string
+	file       *os.File
+	size       int64
+	maxSize    int64
+	maxBackups int
+	clock      Clock
+	// the logger of the server, which receives the errors of the file
+	log *Logger
+}
+
+type logLine struct {
+	Time     time.Time       `json:"time"`
+	Severity DebugSeverity   `json:"severity"`
+	Message  string          `json:"message"`
+	PlayerID string          `json:"player_id,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+}
+
+// openLogFile opens or creates the log file of a game in ServerConfig.LogDir.
+func (s *Server) openLogFile(gameID string) (*logFile, error) {
+	err := os.MkdirAll(s.config.LogDir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("create log directory: %w", err)
+	}
+	l := &logFile{
+		path:       filepath.Join(s.config.LogDir, gameID+".log"),
+		maxSize:    s.config.LogMaxSize,
+		maxBackups: s.config.LogMaxBackups,
+		clock:      s.config.Clock,
+		log:        s.log,
+	}
+	err = l.open()
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *logFile) open() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("open log file: %w", err)
+	}
+	l.file = file
+	l.size = info.Size()
+	return nil
+}
+
+func (l *logFile) rotate() error {
+	err := l.file.Close()
+	l.file = nil
+	if err != nil {
+		return err
+	}
+	if l.maxBackups > 0 {
+		os.Remove(fmt.Sprintf("%s.%d", l.path, l.maxBackups))
+		for i := l.maxBackups - 1; i > 0; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", l.path, i), fmt.Sprintf("%s.%d", l.path, i+1))
+		}
+		err = os.Rename(l.path, l.path+".1")
+	} else {
+		err = os.Remove(l.path)
+	}
+	if err != nil {
+		return err
+	}
+	return l.open()
+}
+
+func (l *logFile) write(line logLine) {
+	data, err := json.Marshal(line)
+	if err != nil {
+		l.log.Error("Failed to encode log line: %s", err)
+		return
+	}
+	data = append(data, '\n')
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.file == nil {
+		return
+	}
+	if l.size > 0 && l.size+int64(len(data)) > l.maxSize {
+		err = l.rotate()
+		if err != nil {
+			l.log.Error("Failed to rotate log file %s: %s", l.path, err)
+			if l.file == nil {
+				return
+			}
+		}
+	}
+	n, err := l.file.Write(data)
+	l.size += int64(n)
+	if err != nil {
+		l.log.Error("Failed to write to log file %s: %s", l.path, err)
+	}
+}
+
+// redactLogFiles removes all lines containing the player ID from the log files in LogDir including the rotated backups,
+// e.g. the player_id lines of the player logger and the messages of the game logger about the player.
+// It returns true if a line has been removed.
+func (s *Server) redactLogFiles(playerID string) bool {
+	if s.config.LogDir == "" {
+		return false
+	}
+	entries, err := os.ReadDir(s.config.LogDir)
+	if err != nil {
+		s.log.Error("Failed to read log directory %s: %s", s.config.LogDir, err)
+		return false
+	}
+
+	// the log files of running games are rewritten under the lock of the game's log file,
+	// which prevents concurrent writes and rotations
+	s.gamesLock.RLock()
+	open := make(map[string]*logFile)
+	for _, g := range s.games {
+		if g.logFile != nil {
+			open[g.logFile.path] = g.logFile
+		}
+	}
+	s.gamesLock.RUnlock()
+
+	redacted := false
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".log") {
+			continue
+		}
+		path := filepath.Join(s.config.LogDir, entry.Name())
+		var changed bool
+		if l, ok := open[path]; ok {
+			changed, err = l.redact(playerID)
+		} else {
+			changed, err = redactLogFile(path, playerID, s.config.LogMaxBackups)
+		}
+		if err != nil {
+			s.log.Error("Failed to redact log file %s: %s", path, err)
+		}
+		if changed {
+			redacted = true
+		}
+	}
+	return redacted
+}
+
+// redact removes the lines containing playerID from the file and its backups.
+func (l *logFile) redact(playerID string) (bool, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.file == nil {
+		return redactLogFile(l.path, playerID, l.maxBackups)
+	}
+	err := l.file.Close()
+	l.file = nil
+	if err != nil {
+		return false, err
+	}
+	changed, err := redactLogFile(l.path, playerID, l.maxBackups)
+	openErr := l.open()
+	if err == nil {
+		err = openErr
+	}
+	return changed, err
+}
+
+// redactLogFile removes the lines containing playerID from the log file at path and its up to maxBackups backups.
+func redactLogFile(path, playerID string, maxBackups int) (bool, error) {
+	changed, err := redactLines(path, playerID)
+	if err != nil {
+		return changed, err
+	}
+	for i := 1; i <= maxBackups; i++ {
+		c, err := redactLines(fmt.Sprintf("%s.%d", path, i), playerID)
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			return changed, err
+		}
+		changed = changed || c
+	}
+	return changed, nil
+}
+
+func redactLines(path, playerID string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	id := []byte(playerID)
+	if !bytes.Contains(data, id) {
+		return false, nil
+	}
+	kept := make([]byte, 0, len(data))
+	for _, line := range bytes.SplitAfter(data, []byte{'\n'}) {
+		if !bytes.Contains(line, id) {
+			kept = append(kept, line...)
+		}
+	}
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, kept, 0o644)
+	if err != nil {
+		return false, err
+	}
+	return true, os.Rename(tmp, path)
+}
+
+func (l *logFile) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// fileSink writes the messages of a game or player logger to the log file of the game.
+type fileSink struct {
+	file     *logFile
+	playerID string
+}
+
+func (f fileSink) Log(severity DebugSeverity, message string, data json.RawMessage) {
+	f.file.write(logLine{
+		Time:     f.file.clock.Now(),
+		Severity: severity,
+		Message:  message,
+		PlayerID: f.playerID,
+		Data:     data,
+	})
+}
@@ -0,0 +1,47 @@
+package cg
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Fork creates a new private game with the config of g, which is pre-loaded with the snapshot returned by OnFork,
+// e.g. to let players branch off an analysis sandbox from a live match.
+// The snapshot is available to the new game with ForkSnapshot before its runGameFunc is called.
+func (g *Game) Fork() (*Game, error) {
+	if g.OnFork == nil {
+		return nil, errors.New("game does not support forking")
+	}
+
+	config, err := json.Marshal(g.Config())
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := g.OnFork()
+	id, _, err := g.server.createGameWith(false, false, g.trial, config, func(fork *Game) {
+		fork.forkedFrom = g.ID
+		fork.forkSnapshot = snapshot
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fork, ok := g.server.getGame(id)
+	if !ok {
+		return nil, errors.New("forked game closed immediately")
+	}
+
+	g.Log.Info("Forked the game into %s.", id)
+	return fork, nil
+}
+
+// ForkedFrom returns the ID of the game this game was forked from (empty => not a fork).
+func (g *Game) ForkedFrom() string {
+	return g.forkedFrom
+}
+
+// ForkSnapshot returns the snapshot of the game this game was forked from or nil if it is not a fork.
+func (g *Game) ForkSnapshot() any {
+	return g.forkSnapshot
+}
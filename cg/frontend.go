@@ -1,10 +1,16 @@
 package cg
 
 import (
+	"bytes"
+	"html/template"
+	"io"
 	"io/fs"
+	"mime"
 	"net/http"
 	"path"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -12,15 +18,40 @@ import (
 func (s *Server) frontendRoutes(r chi.Router) {
 	if s.config.Frontend != nil {
 		r.Mount("/", &frontendHandler{
+			server:   s,
 			frontend: s.config.Frontend,
+			cache:    make(map[string]*frontendCacheEntry),
 		})
 	}
 }
 
 type frontendHandler struct {
+	server   *Server
 	frontend fs.FS
+
+	cacheLock sync.Mutex
+	cache     map[string]*frontendCacheEntry
+}
+
+type frontendTemplateData struct {
+	Name          string
+	DisplayName   string
+	Version       string
+	Description   string
+	RepositoryURL string
+	CGVersion     string
+	APIURL        string
 }
 
+type FrontendMode string
+
+const (
+	// Missing files are answered with index.html if the request is a page navigation.
+	FrontendSPA FrontendMode = "spa"
+	// Missing files are answered with 404 Not Found.
+	FrontendStatic FrontendMode = "static"
+)
+
 func (f *frontendHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	httpFS := http.FS(f.frontend)
 
@@ -30,34 +61,142 @@ func (f *frontendHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	upath = path.Clean(upath)
 
-	var file http.File
-	var err error
-	file, err = httpFS.Open(upath)
+	name := upath
+	file, info, err := openFile(httpFS, name)
+	if err != nil {
+		name = upath + ".html"
+		file, info, err = openFile(httpFS, name)
+	}
+	if err == nil && info.IsDir() {
+		file.Close()
+		name = path.Join(upath, "index.html")
+		file, info, err = openFile(httpFS, name)
+	}
 	if err != nil {
-		file, err = httpFS.Open(upath + ".html")
+		if f.server.config.FrontendMode == FrontendSPA && isNavigation(r, upath) {
+			name = "/index.html"
+			file, info, err = openFile(httpFS, name)
+		}
 		if err != nil {
-			file, err = httpFS.Open("index.html")
-			if err != nil {
-				http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
-				return
-			}
+			f.notFound(w, r)
+			return
 		}
 	}
 	defer file.Close()
 
-	info, err := file.Stat()
-	if err != nil {
-		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	f.serveFile(w, r, http.StatusOK, name, info, file)
+}
+
+func (f *frontendHandler) notFound(w http.ResponseWriter, r *http.Request) {
+	if f.server.config.FrontendNotFoundPage != "" {
+		name := path.Join("/", f.server.config.FrontendNotFoundPage)
+		file, info, err := openFile(http.FS(f.frontend), name)
+		if err == nil && !info.IsDir() {
+			defer file.Close()
+			f.serveFile(w, r, http.StatusNotFound, name, info, file)
+			return
+		}
+		f.server.log.Error("Couldn't open frontend 404 page '%s'.", f.server.config.FrontendNotFoundPage)
+	}
+	http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+}
+
+func (f *frontendHandler) serveFile(w http.ResponseWriter, r *http.Request, status int, name string, info fs.FileInfo, file http.File) {
+	if f.server.config.FrontendTemplates && strings.HasSuffix(name, ".html") {
+		f.serveTemplate(w, r, status, name, file)
 		return
 	}
-	if info.IsDir() {
-		file, err = httpFS.Open(path.Join(strings.TrimPrefix(upath, "/"), "index.html"))
+
+	if status == http.StatusOK {
+		entry, err := f.cacheEntry(name, info, file)
 		if err != nil {
-			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
-		defer file.Close()
+		f.serveContent(w, r, name, info.ModTime(), entry, file)
+		return
+	}
+
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.WriteHeader(status)
+	io.Copy(w, file)
+}
+
+func (f *frontendHandler) serveTemplate(w http.ResponseWriter, r *http.Request, status int, name string, file http.File) {
+	content, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	tmpl, err := template.New(name).Parse(string(content))
+	if err != nil {
+		f.server.log.Error("Failed to parse frontend template '%s': %s", name, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	config := f.server.config
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, frontendTemplateData{
+		Name:          config.Name,
+		DisplayName:   config.DisplayName,
+		Version:       config.Version,
+		Description:   config.Description,
+		RepositoryURL: config.RepositoryURL,
+		CGVersion:     CGVersion,
+		APIURL:        f.server.apiURL(r),
+	})
+	if err != nil {
+		f.server.log.Error("Failed to execute frontend template '%s': %s", name, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
 	}
 
-	http.ServeContent(w, r, upath, info.ModTime(), file)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+		w.Write(buf.Bytes())
+		return
+	}
+	f.serveContent(w, r, name, time.Time{}, newFrontendCacheEntry(name, time.Time{}, buf.Bytes()), bytes.NewReader(buf.Bytes()))
+}
+
+func openFile(httpFS http.FileSystem, name string) (http.File, fs.FileInfo, error) {
+	file, err := httpFS.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return file, info, nil
+}
+
+// isNavigation reports whether the request was made by a browser navigating to a page
+// in contrast to a request for an asset like a script or an image.
+func isNavigation(r *http.Request, upath string) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if ext := path.Ext(upath); ext != "" && ext != ".html" {
+		return false
+	}
+	return r.Header.Get("Sec-Fetch-Mode") == "navigate" || strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// apiURL returns the base URL of the API as seen by the client.
+func (s *Server) apiURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host + s.apiPath
 }
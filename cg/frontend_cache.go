@@ -0,0 +1,148 @@
+package cg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// Files smaller than this are not worth compressing.
+const minGzipSize = 1024
+
+type frontendCacheEntry struct {
+	modTime     time.Time
+	size        int64
+	etag        string
+	contentType string
+	gzipped     []byte
+}
+
+func (f *frontendHandler) cacheEntry(name string, info fs.FileInfo, file http.File) (*frontendCacheEntry, error) {
+	f.cacheLock.Lock()
+	defer f.cacheLock.Unlock()
+
+	if entry, ok := f.cache[name]; ok && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+		return entry, nil
+	}
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := newFrontendCacheEntry(name, info.ModTime(), content)
+	f.cache[name] = entry
+	return entry, nil
+}
+
+func newFrontendCacheEntry(name string, modTime time.Time, content []byte) *frontendCacheEntry {
+	hash := sha256.Sum256(content)
+	entry := &frontendCacheEntry{
+		modTime:     modTime,
+		size:        int64(len(content)),
+		etag:        hex.EncodeToString(hash[:16]),
+		contentType: mime.TypeByExtension(path.Ext(name)),
+	}
+	if entry.contentType == "" {
+		entry.contentType = http.DetectContentType(content)
+	}
+
+	if len(content) >= minGzipSize && isCompressible(entry.contentType) {
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		writer.Write(content)
+		writer.Close()
+		if buf.Len() < len(content) {
+			entry.gzipped = buf.Bytes()
+		}
+	}
+
+	return entry
+}
+
+func (f *frontendHandler) serveContent(w http.ResponseWriter, r *http.Request, name string, modTime time.Time, entry *frontendCacheEntry, content io.ReadSeeker) {
+	w.Header().Set("Content-Type", entry.contentType)
+	w.Header().Set("Cache-Control", cacheControl(name))
+
+	if entry.gzipped == nil {
+		w.Header().Set("ETag", `"`+entry.etag+`"`)
+		http.ServeContent(w, r, name, modTime, content)
+		return
+	}
+
+	w.Header().Add("Vary", "Accept-Encoding")
+	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("ETag", `"`+entry.etag+`-gzip"`)
+		http.ServeContent(w, r, name, modTime, bytes.NewReader(entry.gzipped))
+		return
+	}
+
+	w.Header().Set("ETag", `"`+entry.etag+`"`)
+	http.ServeContent(w, r, name, modTime, content)
+}
+
+// cacheControl returns the Cache-Control header value for the file.
+// Assets with a content hash in their name never change and can be cached forever,
+// everything else has to be revalidated with the ETag.
+func cacheControl(name string) string {
+	if isHashedAsset(name) {
+		return "public, max-age=31536000, immutable"
+	}
+	return "no-cache"
+}
+
+// isHashedAsset reports whether the file name contains a content hash as added by most bundlers, e.g. index-4f3a2b1c.js.
+func isHashedAsset(name string) bool {
+	base := path.Base(name)
+	ext := path.Ext(base)
+	if ext == "" || ext == ".html" {
+		return false
+	}
+	parts := strings.FieldsFunc(strings.TrimSuffix(base, ext), func(r rune) bool {
+		return r == '.' || r == '-'
+	})
+	for i, part := range parts {
+		if i > 0 && isHash(part) {
+			return true
+		}
+	}
+	return false
+}
+
+func isHash(s string) bool {
+	if len(s) < 8 {
+		return false
+	}
+	digit := false
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			digit = true
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		default:
+			return false
+		}
+	}
+	return digit
+}
+
+func isCompressible(contentType string) bool {
+	return strings.HasPrefix(contentType, "text/") ||
+		strings.Contains(contentType, "javascript") ||
+		strings.Contains(contentType, "json") ||
+		strings.Contains(contentType, "xml") ||
+		strings.Contains(contentType, "wasm")
+}
@@ -3,6 +3,7 @@ package cg
 import (
 	"encoding/json"
 	"errors"
+	"sort"
 	"sync"
 	"time"
 
@@ -16,12 +17,61 @@ type Game struct {
 	OnPlayerLeft            func(player *Player)
 	OnPlayerSocketConnected func(player *Player, socket *GameSocket)
 	OnSpectatorConnected    func(socket *GameSocket)
+	// OnSpectatorDisconnected is called when a spectator socket has been disconnected or stopped spectating the game.
+	OnSpectatorDisconnected func(socket *GameSocket)
+	// OnSpectatorCountChanged is called with the new number of spectators whenever a spectator connects or disconnects,
+	// e.g. to only produce spectator-only events while someone is watching.
+	OnSpectatorCountChanged func(count int)
+	// OnPlayerDisconnected is called when the last socket of a player has been disconnected
+	// for longer than ReconnectGracePeriod.
+	OnPlayerDisconnected func(player *Player)
+	// OnResyncRequest is called when a player connects a socket after its first one.
+	// The returned snapshot of the game state is sent to the new socket with the cg_resync event
+	// instead of the events the player missed while it had no sockets.
+	// It is called concurrently to the game loop.
+	OnResyncRequest func(player *Player) any
+	// OnSpectatorNeedsSnapshot is called when a spectator connects. The returned snapshot of the game state
+	// is sent to the spectator with the cg_resync event before OnSpectatorConnected is called.
+	// It is called concurrently to the game loop.
+	OnSpectatorNeedsSnapshot func(socket *GameSocket) any
+	// MissedEventCoalescer reduces the events a player missed while it had no sockets before they are sent
+	// to its new socket, e.g. by dropping stale position updates.
+	MissedEventCoalescer func(events []Event) []Event
+	// OnSettingsChanged is called with the new config after the host updated the settings of the game.
+	OnSettingsChanged func(config any)
+	// OnInviteAnswered is called when the recipient of an invite created with Server.InvitePlayer accepted or declined it.
+	OnInviteAnswered func(invite *Invite, accepted bool)
+	// OnFork returns a snapshot of the game state which Fork passes to the new game.
+	OnFork func() any
+	// OnStalled is called with the number of queued commands when the game loop has not taken a command
+	// for ZombieTimeout while commands were pending. It is called once per stall from the watchdog goroutine,
+	// because the game loop is presumably blocked.
+	OnStalled func(queued int)
+	// OnConnectionQuality is called for every player every ConnectionQualityInterval
+	// on the goroutine consuming the command queue.
+	OnConnectionQuality func(player *Player, quality ConnectionQuality)
+
+	// CommandValidator is called for every command sent by a player before it is added to the command queue.
+	// Returning an error rejects the command and adds a strike to the player.
+	// It is called concurrently from the goroutines of the connected sockets.
+	CommandValidator func(player *Player, cmd Command) error
 
 	Log *Logger
 
-	config any
+	configLock sync.RWMutex
+	config     any
+	hostID     string
+	visibility Visibility
+	features   map[string]bool
+	region     string
 
+	cmdLock sync.RWMutex
 	cmdChan chan CommandWrapper
+	closing chan struct{}
+
+	// commands skipped by AwaitCommand
+	pendingLock sync.Mutex
+	pending     []CommandWrapper
 
 	public     bool
 	joinSecret string
@@ -32,9 +82,62 @@ type Game struct {
 	spectatorsLock sync.RWMutex
 	spectators     map[string]*GameSocket
 
+	rejectedCommandsLock sync.RWMutex
+	rejectedCommands     []RejectedCommand
+
+	bannedLock          sync.RWMutex
+	bannedAddresses     map[string]struct{}
+	bannedClientSecrets map[string]struct{}
+
+	votesLock sync.RWMutex
+	votes     map[string]*Vote
+
+	summaryEventsLock sync.RWMutex
+	summaryEvents     map[EventName]struct{}
+
+	lowPriorityLock sync.RWMutex
+	lowPriority     map[EventName]struct{}
+
+	// the games which may exchange commands with SendToGame
+	linksLock sync.RWMutex
+	links     map[string]struct{}
+
+	bandwidth bandwidth
+
+	createdAt time.Time
+	statsLock sync.Mutex
+	stats     gameStats
+
 	server *Server
 
-	running bool
+	// the file the game and player loggers append to, see ServerConfig.LogDir
+	logFile *logFile
+
+	closingLock sync.Mutex
+	// guarded by closingLock
+	running      bool
+	closingState *closingState
+	closeReason  string
+	// the final results of a game closed with CloseGracefully
+	results any
+
+	// the time since which the queued commands have been waiting for the game loop,
+	// i.e. the last time the queue became non-empty or the game loop took something from it
+	consumedLock sync.Mutex
+	waitingSince time.Time
+	stalled      bool
+
+	forkedFrom   string
+	forkSnapshot any
+
+	trial bool
+	// the preset of an autostart game, see ServerConfig.AutostartGames
+	autostart GamePreset
+	// the delay after which the autostart game was created
+	autostartDelay time.Duration
+
+	startedOnce sync.Once
+	started     chan struct{}
 
 	markedAsEmpty time.Time
 }
@@ -45,22 +148,60 @@ type EventWrapper struct {
 }
 
 func newGame(server *Server, id string, public bool) *Game {
-	return &Game{
+	game := &Game{
 		ID:         id,
 		Log:        NewLogger(false),
 		cmdChan:    make(chan CommandWrapper, 10),
+		closing:    make(chan struct{}),
 		public:     public,
 		players:    make(map[string]*Player),
 		spectators: make(map[string]*GameSocket),
 		server:     server,
 		running:    true,
+		createdAt:  server.config.Clock.Now(),
+		started:    make(chan struct{}),
+
+		bannedAddresses:     make(map[string]struct{}),
+		bannedClientSecrets: make(map[string]struct{}),
+		votes:               make(map[string]*Vote),
+		lowPriority:         make(map[EventName]struct{}),
+		summaryEvents:       make(map[EventName]struct{}),
+		links:               make(map[string]struct{}),
 	}
+	game.Log.SetTraceSampling(server.config.TraceSampling)
+	if server.config.LogDir != "" {
+		logFile, err := server.openLogFile(id)
+		if err != nil {
+			server.log.Error("Failed to open log file of game %s: %s", id, err)
+		} else {
+			game.logFile = logFile
+			game.Log.SetSink(fileSink{file: logFile})
+		}
+	}
+	if server.config.ConnectionQualityInterval > 0 {
+		go game.reportConnectionQuality()
+	}
+	return game
 }
 
 // Set game config data. This should be a struct of type GameConfig.
 // It is required to call this function in order for some API endpoints to work.
 func (g *Game) SetConfig(config any) {
+	g.configLock.Lock()
 	g.config = config
+	g.configLock.Unlock()
+}
+
+// Config returns the game config data set with SetConfig or UpdateSettings.
+func (g *Game) Config() any {
+	g.configLock.RLock()
+	defer g.configLock.RUnlock()
+	return g.config
+}
+
+// Clock returns the clock of the server, which should be used for all timers of the game.
+func (g *Game) Clock() Clock {
+	return g.server.config.Clock
 }
 
 // Send sends the event to all players currently in the game.
@@ -79,26 +220,31 @@ func (g *Game) Send(event EventName, data any) error {
 	}
 
 	g.Log.TraceData(e, "Broadcasting '%s' event to all players...", e.Name)
+	g.countBroadcast()
+
+	// a failed write must not keep the event from the remaining recipients
+	var sendErr error
 
 	g.playersLock.RLock()
 	defer g.playersLock.RUnlock()
 	for _, p := range g.players {
-		err := p.sendEncoded(jsonData)
+		err := p.sendEncoded(e.Name, jsonData)
 		if err != nil {
-			return err
+			sendErr = err
 		}
 	}
 
 	g.spectatorsLock.RLock()
 	defer g.spectatorsLock.RUnlock()
 	for _, s := range g.spectators {
-		err := s.send(jsonData)
+		err := g.sendToSpectator(s, e, jsonData)
 		if err != nil {
-			return err
+			g.Log.Trace("Failed to send '%s' event to spectator %s: %s", e.Name, s.ID, err)
+			sendErr = err
 		}
 	}
 
-	return nil
+	return sendErr
 }
 
 // GetPlayer returns a player in the game by id.
@@ -109,67 +255,195 @@ func (g *Game) GetPlayer(playerID string) (*Player, bool) {
 	return player, ok
 }
 
+// Players returns a snapshot of all players in the game ordered by the time they joined.
+// The slice is not updated when players join or leave.
+func (g *Game) Players() []*Player {
+	g.playersLock.RLock()
+	players := make([]*Player, 0, len(g.players))
+	for _, p := range g.players {
+		players = append(players, p)
+	}
+	g.playersLock.RUnlock()
+
+	sort.Slice(players, func(i, j int) bool {
+		if players[i].joinedAt.Equal(players[j].joinedAt) {
+			return players[i].ID < players[j].ID
+		}
+		return players[i].joinedAt.Before(players[j].joinedAt)
+	})
+	return players
+}
+
+// ForEachPlayer calls fn for every player in the order of Players.
+// No locks are held while fn is executed, so fn may call any method of the game.
+func (g *Game) ForEachPlayer(fn func(player *Player)) {
+	for _, p := range g.Players() {
+		fn(p)
+	}
+}
+
+// PlayerCount returns the number of players in the game.
+func (g *Game) PlayerCount() int {
+	g.playersLock.RLock()
+	defer g.playersLock.RUnlock()
+	return len(g.players)
+}
+
 // NextCommand returns the next command in the queue or ok = false if there is none.
+// Functions scheduled with Schedule or ScheduleAt which are due are executed before.
 func (g *Game) NextCommand() (CommandWrapper, bool) {
-	select {
-	case wrapper, ok := <-g.cmdChan:
-		if ok {
+	g.markStarted()
+	if wrapper, ok := g.nextPending(); ok {
+		return wrapper, true
+	}
+	for {
+		select {
+		case wrapper, ok := <-g.cmdChan:
+			if !ok {
+				return CommandWrapper{}, false
+			}
+			g.markConsumed()
+			if wrapper.scheduled != nil {
+				wrapper.scheduled()
+				continue
+			}
+			g.countCommand()
+			wrapper.endSpan()
 			return wrapper, true
-		} else {
+		default:
 			return CommandWrapper{}, false
 		}
-	default:
-		return CommandWrapper{}, false
 	}
 }
 
 // WaitForNextCommand waits for and then returns the next command in the queue or ok = false if the game has been closed.
+// Functions scheduled with Schedule or ScheduleAt are executed while waiting.
 func (g *Game) WaitForNextCommand() (CommandWrapper, bool) {
-	wrapper, ok := <-g.cmdChan
-	return wrapper, ok
+	g.markStarted()
+	if wrapper, ok := g.nextPending(); ok {
+		return wrapper, true
+	}
+	for {
+		wrapper, ok := <-g.cmdChan
+		if ok {
+			g.markConsumed()
+		}
+		if ok && wrapper.scheduled != nil {
+			wrapper.scheduled()
+			continue
+		}
+		if ok {
+			g.countCommand()
+			wrapper.endSpan()
+		}
+		return wrapper, ok
+	}
+}
+
+// enqueue adds the wrapper to the command queue. It returns false if the game has been closed.
+func (g *Game) enqueue(wrapper CommandWrapper) bool {
+	g.cmdLock.RLock()
+	defer g.cmdLock.RUnlock()
+	select {
+	case <-g.closing:
+		return false
+	default:
+	}
+	g.markQueued()
+	select {
+	case g.cmdChan <- wrapper:
+		return true
+	case <-g.closing:
+		return false
+	}
 }
 
 // Returns true if the game has not already been closed.
 func (g *Game) Running() bool {
+	g.closingLock.Lock()
+	defer g.closingLock.Unlock()
 	return g.running
 }
 
 // Stop the game, disconnect all players and remove it from the server.
+// Only the first call closes the game, so it is safe to call Close concurrently.
 func (g *Game) Close() error {
+	g.closingLock.Lock()
 	if !g.running {
+		g.closingLock.Unlock()
 		return nil
 	}
-
 	g.running = false
+	g.closingLock.Unlock()
 
 	g.server.removeGame(g)
 
-	for _, p := range g.players {
+	for _, p := range g.Players() {
 		err := g.leave(p)
 		if err != nil {
 			g.Log.Error("Couldn't disconnect player '%s': %s", p.ID, err)
 		}
 	}
 
+	g.spectatorsLock.RLock()
+	spectators := make([]*GameSocket, 0, len(g.spectators))
+	for _, s := range g.spectators {
+		spectators = append(spectators, s)
+	}
+	g.spectatorsLock.RUnlock()
+	// the spectators are disconnected in parallel, so that a slow spectator cannot delay the others
+	var disconnects sync.WaitGroup
+	for _, s := range spectators {
+		if s.isMultiSpectator() {
+			s.spectatingLock.Lock()
+			delete(s.spectating, g.ID)
+			s.spectatingLock.Unlock()
+			g.removeSpectator(s.ID)
+		} else {
+			disconnects.Add(1)
+			go func(s *GameSocket) {
+				defer disconnects.Done()
+				s.disconnect()
+			}(s)
+		}
+	}
+	disconnects.Wait()
+
+	close(g.closing)
+	g.cmdLock.Lock()
 	close(g.cmdChan)
+	g.cmdLock.Unlock()
 
 	g.server.log.Info("Removed game %s.", g.ID)
+	if g.public {
+		g.server.notify(NotificationGameClosed, g.ID, "The game %s has been closed.", g.ID)
+	}
 
 	g.Log.Close()
+	if g.logFile != nil {
+		g.logFile.Close()
+	}
 
 	return nil
 }
 
-func (g *Game) join(username, joinSecret string) (string, string, error) {
+func (g *Game) join(username, joinSecret, address, lang, clientSecret string) (string, string, error) {
 	if g.joinSecret != "" && g.joinSecret != joinSecret {
+		if err := g.server.checkSecretFormat(joinSecret); err != nil {
+			return "", "", err
+		}
 		return "", "", errors.New("wrong join secret")
 	}
 
-	if g.server.config.MaxPlayersPerGame > 0 {
+	if g.isBanned(address, clientSecret) {
+		return "", "", errors.New("banned from this game")
+	}
+
+	if maxPlayers := g.maxPlayers(); maxPlayers > 0 {
 		g.playersLock.RLock()
 		playerCount := len(g.players)
 		g.playersLock.RUnlock()
-		if playerCount >= g.server.config.MaxPlayersPerGame {
+		if playerCount >= maxPlayers {
 			return "", "", errors.New("max player count reached")
 		}
 	}
@@ -180,19 +454,37 @@ func (g *Game) join(username, joinSecret string) (string, string, error) {
 	player := &Player{
 		ID:           playerID,
 		Username:     username,
-		Secret:       generateSecret(),
+		Secret:       g.server.generateSecret(),
+		joinedAt:     g.server.config.Clock.Now(),
+		offline:      true,
 		Log:          NewLogger(false),
+		address:      address,
+		lang:         lang,
+		clientSecret: clientSecret,
 		server:       g.server,
 		sockets:      make(map[string]*GameSocket),
 		game:         g,
 		missedEvents: make([][]byte, 0),
+		timers:       make(map[string]*PlayerTimer),
+	}
+
+	player.Log.SetTraceSampling(g.server.config.TraceSampling)
+	if g.logFile != nil {
+		player.Log.SetSink(fileSink{file: g.logFile, playerID: playerID})
 	}
 
 	g.playersLock.Lock()
+	player.colorIndex = g.nextColorIndex()
 	g.players[playerID] = player
 	g.playersLock.Unlock()
 
-	g.Log.Info("Player '%s' (%s) joined the game.", player.Username, player.ID)
+	g.configLock.Lock()
+	if g.hostID == "" {
+		g.hostID = player.ID
+	}
+	g.configLock.Unlock()
+
+	g.Log.Info("Player '%s' (%s) joined the game.", player.username(), player.ID)
 
 	if g.OnPlayerJoined != nil {
 		g.OnPlayerJoined(player)
@@ -201,8 +493,17 @@ func (g *Game) join(username, joinSecret string) (string, string, error) {
 	return player.ID, player.Secret, nil
 }
 
+// leave removes the player from the game. Only the first call for a player has an effect.
 func (g *Game) leave(player *Player) error {
-	if g.running {
+	g.playersLock.Lock()
+	if player.left {
+		g.playersLock.Unlock()
+		return nil
+	}
+	player.left = true
+	g.playersLock.Unlock()
+
+	if g.Running() {
 		if g.OnPlayerLeft != nil {
 			g.OnPlayerLeft(player)
 		}
@@ -213,24 +514,100 @@ func (g *Game) leave(player *Player) error {
 	playerCount := len(g.players)
 	g.playersLock.Unlock()
 
-	for _, socket := range player.sockets {
-		player.disconnectSocket(socket.ID)
+	g.configLock.Lock()
+	if g.hostID == player.ID {
+		g.hostID = g.oldestPlayerID()
 	}
+	g.configLock.Unlock()
 
-	g.Log.Info("Player '%s' (%s) left the game %s", player.ID, player.Username, player.game.ID)
+	player.socketsLock.RLock()
+	socketIDs := make([]string, 0, len(player.sockets))
+	for id := range player.sockets {
+		socketIDs = append(socketIDs, id)
+	}
+	player.socketsLock.RUnlock()
+	for _, id := range socketIDs {
+		player.disconnectSocket(id)
+	}
+
+	g.Log.Info("Player '%s' (%s) left the game %s", player.ID, player.username(), player.game.ID)
 
 	if playerCount == 0 {
-		g.markedAsEmpty = time.Now()
+		g.markedAsEmpty = g.server.config.Clock.Now()
 	}
 
 	return nil
 }
 
+func (g *Game) forget(player *Player) {
+	g.leave(player)
+
+	player.missedEventsLock.Lock()
+	player.missedEvents = nil
+	player.missedEventsLock.Unlock()
+
+	player.infoLock.Lock()
+	player.Username = ""
+	player.address = ""
+	player.infoLock.Unlock()
+	player.Log.Close()
+}
+
+// purgePlayer removes the data the game keeps about the player with the ID playerID,
+// which may have already left the game. It returns true if there was any data.
+func (g *Game) purgePlayer(playerID string) bool {
+	g.rejectedCommandsLock.Lock()
+	defer g.rejectedCommandsLock.Unlock()
+	rejected := make([]RejectedCommand, 0, len(g.rejectedCommands))
+	for _, r := range g.rejectedCommands {
+		if r.PlayerID != playerID {
+			rejected = append(rejected, r)
+		}
+	}
+	found := len(rejected) != len(g.rejectedCommands)
+	g.rejectedCommands = rejected
+	return found
+}
+
+func (g *Game) oldestPlayerID() string {
+	g.playersLock.RLock()
+	defer g.playersLock.RUnlock()
+	var oldest *Player
+	for _, p := range g.players {
+		if oldest == nil || p.joinedAt.Before(oldest.joinedAt) {
+			oldest = p
+		}
+	}
+	if oldest == nil {
+		return ""
+	}
+	return oldest.ID
+}
+
+// authorizePlayerList returns true if playerSecret belongs to a player of the game
+// or joinSecret is the join secret of the protected game.
+func (g *Game) authorizePlayerList(playerSecret, joinSecret string) bool {
+	if joinSecret != "" && g.joinSecret == joinSecret {
+		return true
+	}
+	if playerSecret == "" {
+		return false
+	}
+	g.playersLock.RLock()
+	defer g.playersLock.RUnlock()
+	for _, p := range g.players {
+		if p.Secret == playerSecret {
+			return true
+		}
+	}
+	return false
+}
+
 func (g *Game) playerUsernameMap() map[string]string {
 	g.playersLock.RLock()
 	usernameMap := make(map[string]string, len(g.players))
 	for id, player := range g.players {
-		usernameMap[id] = player.Username
+		usernameMap[id] = player.username()
 	}
 	g.playersLock.RUnlock()
 	return usernameMap
@@ -243,21 +620,49 @@ func (g *Game) addSpectator(socket *GameSocket) error {
 		return errors.New("max spectator count reached")
 	}
 
-	socket.spectateGame = g
+	if !socket.isMultiSpectator() {
+		socket.spectateGame = g
+	}
 	g.spectators[socket.ID] = socket
+	count := len(g.spectators)
+	// sent while holding the lock so that no broadcast event can precede it
+	socket.sendAuthenticated()
 	g.spectatorsLock.Unlock()
 
+	if g.OnSpectatorNeedsSnapshot != nil {
+		err := g.sendDataToSpectator(socket, EventResync, g.OnSpectatorNeedsSnapshot(socket))
+		if err != nil {
+			g.Log.Error("Failed to send snapshot to spectator %s: %s", socket.ID, err)
+		}
+	}
+
 	if g.OnSpectatorConnected != nil {
 		g.OnSpectatorConnected(socket)
 	}
+	if g.OnSpectatorCountChanged != nil {
+		g.OnSpectatorCountChanged(count)
+	}
 
 	return nil
 }
 
 func (g *Game) removeSpectator(id string) {
 	g.spectatorsLock.Lock()
+	socket, ok := g.spectators[id]
 	delete(g.spectators, id)
+	count := len(g.spectators)
 	g.spectatorsLock.Unlock()
+
+	// a socket may be removed both when it is dropped and when its connection handler exits
+	if !ok {
+		return
+	}
+	if g.OnSpectatorDisconnected != nil {
+		g.OnSpectatorDisconnected(socket)
+	}
+	if g.OnSpectatorCountChanged != nil {
+		g.OnSpectatorCountChanged(count)
+	}
 }
 
 func (g *Game) kickInactivePlayers() {
@@ -265,7 +670,7 @@ func (g *Game) kickInactivePlayers() {
 		g.playersLock.RLock()
 		for _, p := range g.players {
 			p.socketsLock.RLock()
-			if p.socketCount == 0 && time.Since(p.lastConnection) >= g.server.config.KickInactivePlayerDelay {
+			if p.socketCount == 0 && g.server.config.Clock.Now().Sub(p.lastConnection) >= g.server.config.ReconnectGracePeriod+g.server.config.KickInactivePlayerDelay {
 				g.playersLock.RUnlock()
 				p.socketsLock.RUnlock()
 				g.leave(p)
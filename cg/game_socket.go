@@ -3,8 +3,11 @@ package cg
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
 
@@ -13,16 +16,108 @@ type GameSocket struct {
 	server       *Server
 	player       *Player
 	spectateGame *Game
-	conn         *websocket.Conn
+	conn         socketConn
 	done         chan struct{}
+
+	disconnectOnce sync.Once
+
+	writeLock     sync.Mutex
+	writeFailures int
+	dropped       bool
+
+	// the messages waiting to be sent to a spectator (nil => messages are sent directly)
+	queue chan []byte
+	// closed when writeQueue returns
+	queueDone chan struct{}
+	evictOnce sync.Once
+
+	// flushLock is separate from writeLock, which a stalled write holds until WebsocketTimeout
+	flushLock sync.Mutex
+	// the deadline of the writes while flushing the queue (zero => WebsocketTimeout)
+	flushDeadline time.Time
+	// the socket has been evicted or dropped, so the queue is not flushed
+	skipFlush bool
+
+	pongLock sync.Mutex
+	lastPong time.Time
+	lastPing time.Time
+
+	subscriptionsLock sync.RWMutex
+	subscriptions     map[EventName]struct{}
+
+	remoteAddr  string
+	userAgent   string
+	connectedAt time.Time
+
+	tier SpectatorTier
+
+	// send cg_authenticated once the socket has been added to its player or game, see authenticateTCPSocket
+	confirmAuthentication bool
+
+	bandwidth bandwidth
+
+	// the games of a socket connected to /api/spectate (nil => not a multi-game spectator)
+	spectatingLock sync.Mutex
+	spectating     map[string]*Game
+}
+
+type socketInfo struct {
+	ID          string    `json:"id"`
+	RemoteAddr  string    `json:"remote_addr"`
+	UserAgent   string    `json:"user_agent,omitempty"`
+	ConnectedAt time.Time `json:"connected_at"`
+}
+
+// Transport intercepts all messages sent to game sockets, e.g. to simulate bad network conditions in tests.
+type Transport interface {
+	// Send is called for every message sent to socket. write delivers the message and is safe for concurrent use.
+	Send(socket *GameSocket, message []byte, write func(message []byte) error) error
 }
 
 var (
 	ErrInvalidMessageType = errors.New("invalid message type")
 	ErrEncodeFailed       = errors.New("failed to encode json object")
 	ErrDecodeFailed       = errors.New("failed to decode event")
+	ErrSocketDropped      = errors.New("socket has been dropped after repeated write failures")
+	ErrMessageRejected    = errors.New("message rejected")
 )
 
+func (s *Server) newGameSocket(conn socketConn, remoteAddr, userAgent string) *GameSocket {
+	return &GameSocket{
+		ID:          uuid.NewString(),
+		server:      s,
+		conn:        conn,
+		remoteAddr:  remoteAddr,
+		userAgent:   userAgent,
+		connectedAt: s.config.Clock.Now(),
+		done:        make(chan struct{}),
+	}
+}
+
+// RemoteAddr returns the network address of the client which opened the socket.
+func (s *GameSocket) RemoteAddr() string {
+	return s.remoteAddr
+}
+
+// UserAgent returns the User-Agent header sent by the client when opening the socket.
+func (s *GameSocket) UserAgent() string {
+	return s.userAgent
+}
+
+// ConnectedAt returns the time at which the socket connected.
+func (s *GameSocket) ConnectedAt() time.Time {
+	return s.connectedAt
+}
+
+func (s *GameSocket) info() socketInfo {
+	return socketInfo{
+		ID:          s.ID,
+		RemoteAddr:  s.remoteAddr,
+		UserAgent:   s.userAgent,
+		ConnectedAt: s.connectedAt,
+	}
+}
+
 // Send sends the event the socket.
 func (s *GameSocket) Send(event EventName, data any) error {
 	e := Event{
@@ -47,53 +142,127 @@ func (s *GameSocket) Send(event EventName, data any) error {
 }
 
 func (s *GameSocket) handleConnection() {
-	s.done = make(chan struct{})
-
+	// pings of TCP connections are answered locally, so their round trip time says nothing about the connection
+	_, isTCP := s.conn.(*tcpConn)
 	s.conn.SetReadDeadline(time.Now().Add(s.server.config.WebsocketTimeout))
 	s.conn.SetPongHandler(func(string) error {
 		s.conn.SetReadDeadline(time.Now().Add(s.server.config.WebsocketTimeout))
+		s.pongLock.Lock()
+		s.lastPong = s.server.config.Clock.Now()
+		// only the first pong after a ping is measured
+		rtt := s.lastPong.Sub(s.lastPing)
+		measured := !s.lastPing.IsZero()
+		s.lastPing = time.Time{}
+		s.pongLock.Unlock()
+		if measured && !isTCP && s.player != nil {
+			s.player.quality.addLatency(rtt)
+		}
+		return nil
+	})
+
+	// disconnect answers the close frame of the client once the socket has been removed,
+	// so a client knows that events sent after the answer are missed events
+	s.conn.SetCloseHandler(func(int, string) error {
 		return nil
 	})
 
 	go s.ping()
 
+	if s.queue != nil {
+		go s.writeQueue()
+	}
+
 	for {
 		cmd, err := s.receiveCommand()
 		if err != nil {
 			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseNoStatusReceived, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				s.server.log.Trace("Socket %s disconnected.", s.ID)
 				break
-			} else if err == ErrDecodeFailed || err == ErrInvalidMessageType {
+			} else if errors.Is(err, ErrDecodeFailed) || errors.Is(err, ErrInvalidMessageType) || errors.Is(err, ErrMessageRejected) {
 				s.logger().Error("Socket %s failed to decode command: %s", s.ID, err)
+				s.SendError(ErrorDecodeFailed, err.Error())
+				continue
 			} else {
 				s.logger().Trace("Socket %s disconnected unexpectedly: %s", s.ID, err)
 				break
 			}
 		}
 
-		if s.player != nil {
-			s.player.handleCommand(cmd)
+		if s.isMultiSpectator() && (cmd.Name == CommandSpectate || cmd.Name == CommandUnspectate) {
+			err = s.handleSpectateCommand(cmd)
+			if err != nil {
+				s.logger().Error("Socket %s sent an invalid '%s' command: %s", s.ID, cmd.Name, err)
+				s.sendCommandError(cmd, err, ErrorCommandRejected)
+			}
+		} else if cmd.Name == CommandSubscribe || cmd.Name == CommandUnsubscribe {
+			err = s.handleSubscription(cmd)
+			if err != nil {
+				s.logger().Error("Socket %s sent an invalid '%s' command: %s", s.ID, cmd.Name, err)
+				s.sendCommandError(cmd, err, ErrorDecodeFailed)
+			}
+		} else if s.player != nil {
+			err = s.player.handleCommand(cmd)
+			if err != nil {
+				s.sendCommandError(cmd, err, ErrorCommandRejected)
+			}
 		} else {
 			s.logger().Warning("Socket %s sent an unexpected command: %s", s.ID, cmd.Name)
+			s.sendCommandError(cmd, errors.New("spectators cannot send commands"), ErrorUnexpectedCommand)
 		}
 	}
 
 	if s.player != nil {
 		s.player.disconnectSocket(s.ID)
+	} else if s.isMultiSpectator() {
+		s.unspectateAll()
+		s.server.removeMultiSpectator(s.ID)
 	} else {
 		if s.spectateGame != nil {
 			s.spectateGame.removeSpectator(s.ID)
 		}
 	}
+	s.disconnect()
 }
 
+// ping pings the client every PingInterval and disconnects the socket if it fails to answer within PongTimeout.
 func (s *GameSocket) ping() {
-	ticker := time.NewTicker((s.server.config.WebsocketTimeout * 9) / 10)
+	clock := s.server.config.Clock
+	ticker := clock.NewTicker(s.server.config.PingInterval)
 	defer ticker.Stop()
+	var pongTimer Timer
+	defer func() {
+		if pongTimer != nil {
+			pongTimer.Stop()
+		}
+	}()
 	for {
 		select {
-		case <-ticker.C:
-			s.conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(30*time.Second))
+		case <-ticker.C():
+			sentAt := clock.Now()
+			s.pongLock.Lock()
+			s.lastPing = sentAt
+			s.pongLock.Unlock()
+			err := s.conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(s.server.config.PongTimeout))
+			if err != nil {
+				s.logger().Trace("Failed to ping socket %s: %s", s.ID, err)
+				s.Disconnect()
+				return
+			}
+			if pongTimer != nil {
+				pongTimer.Stop()
+			}
+			pongTimer = clock.AfterFunc(s.server.config.PongTimeout, func() {
+				s.pongLock.Lock()
+				answered := !s.lastPong.Before(sentAt)
+				s.pongLock.Unlock()
+				if !answered {
+					if s.player != nil {
+						s.player.quality.addPingFailure(clock.Now())
+					}
+					s.logger().Trace("Socket %s did not answer a ping within %s.", s.ID, s.server.config.PongTimeout)
+					s.Disconnect()
+				}
+			})
 		case <-s.done:
 			return
 		}
@@ -101,9 +270,17 @@ func (s *GameSocket) ping() {
 }
 
 func (s *GameSocket) disconnect() {
-	close(s.done)
-	s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "disconnect"), time.Now().Add(5*time.Second))
-	s.conn.Close()
+	s.disconnectOnce.Do(func() {
+		close(s.done)
+		if s.flushQueue() {
+			reason := "disconnect"
+			if game := s.game(); game != nil && !game.Running() {
+				reason = "game closed"
+			}
+			s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, reason), time.Now().Add(5*time.Second))
+		}
+		s.conn.Close()
+	})
 }
 
 func (s *GameSocket) receiveCommand() (Command, error) {
@@ -111,7 +288,14 @@ func (s *GameSocket) receiveCommand() (Command, error) {
 	if err != nil {
 		return Command{}, err
 	}
-	if msgType != websocket.TextMessage {
+	s.countReceived(len(msg))
+	if s.server.config.OnRawMessage != nil {
+		// the hook may decode custom binary encodings
+		msg, err = s.server.config.OnRawMessage(s, msg)
+		if err != nil {
+			return Command{}, fmt.Errorf("%w: %s", ErrMessageRejected, err)
+		}
+	} else if msgType != websocket.TextMessage {
 		return Command{}, ErrInvalidMessageType
 	}
 
@@ -127,9 +311,75 @@ func (s *GameSocket) receiveCommand() (Command, error) {
 	return cmd, nil
 }
 
+// Disconnect closes the connection of the socket without a close handshake as if the network connection was lost.
+func (s *GameSocket) Disconnect() {
+	s.conn.Close()
+}
+
+// sendEvent sends the encoded event if the socket is subscribed to it.
+func (s *GameSocket) sendEvent(event EventName, message []byte) error {
+	if !s.isSubscribed(event) || !s.acceptsTier(event) || s.shed(event) {
+		return nil
+	}
+	return s.send(message)
+}
+
 func (s *GameSocket) send(message []byte) error {
-	s.conn.SetWriteDeadline(time.Now().Add(s.server.config.WebsocketTimeout))
-	return s.conn.WriteMessage(websocket.TextMessage, message)
+	s.countSent(len(message))
+	if s.queue != nil {
+		return s.enqueue(message)
+	}
+	return s.deliver(message)
+}
+
+func (s *GameSocket) deliver(message []byte) error {
+	if s.server.config.Transport != nil {
+		return s.server.config.Transport.Send(s, message, s.write)
+	}
+	return s.write(message)
+}
+
+func (s *GameSocket) write(message []byte) error {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+	if s.dropped {
+		return ErrSocketDropped
+	}
+	deadline := time.Now().Add(s.server.config.WebsocketTimeout)
+	s.flushLock.Lock()
+	if !s.flushDeadline.IsZero() {
+		deadline = s.flushDeadline
+	}
+	s.flushLock.Unlock()
+	s.conn.SetWriteDeadline(deadline)
+	err := s.conn.WriteMessage(websocket.TextMessage, message)
+	if err == nil {
+		s.writeFailures = 0
+		return nil
+	}
+	s.writeFailures++
+	if s.writeFailures >= s.server.config.MaxSocketWriteFailures {
+		s.dropped = true
+		s.logger().Warning("Disconnecting socket %s after %d failed writes: %s", s.ID, s.writeFailures, err)
+		// the callers of write may hold the locks required to deregister the socket
+		go s.drop()
+	}
+	return err
+}
+
+// drop deregisters the socket from its player or game and closes the connection.
+func (s *GameSocket) drop() {
+	s.flushLock.Lock()
+	s.skipFlush = true
+	s.flushLock.Unlock()
+	if s.player != nil {
+		s.player.disconnectSocket(s.ID)
+	} else if s.isMultiSpectator() {
+		s.unspectateAll()
+	} else if s.spectateGame != nil {
+		s.spectateGame.removeSpectator(s.ID)
+	}
+	s.conn.Close()
 }
 
 func (s *GameSocket) logger() *Logger {
@@ -0,0 +1,68 @@
+package cg
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+var (
+	ErrMaxGamesReached  = errors.New("max game count reached")
+	ErrNoGameFunc       = errors.New("no game function has been provided")
+	ErrGameInitFailed   = errors.New("game failed to initialize")
+	errGameFuncReturned = errors.New("game function returned before polling commands")
+)
+
+const defaultGameInitTimeout = time.Second
+
+// markStarted is called when the game loop polls the command queue for the first time,
+// which completes the initialization of the game.
+func (g *Game) markStarted() {
+	g.startedOnce.Do(func() {
+		close(g.started)
+	})
+}
+
+// startGame runs runGameFunc for game in a new goroutine and waits until the game loop polls the command queue
+// for the first time or GameInitTimeout has passed. If runGameFunc panics or returns before, the game is closed and an error is returned.
+// A panic of runGameFunc after the initialization closes the game instead of crashing the server.
+// The caller must have added 1 to gameFuncs.
+func (s *Server) startGame(game *Game, config []byte) error {
+	failed := make(chan error, 1)
+	go func() {
+		defer s.gameFuncs.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				game.Log.Error("The game function panicked: %v", r)
+				s.log.Error("The game function of game %s panicked: %v\n%s", game.ID, r, debug.Stack())
+				failed <- fmt.Errorf("%w: %v", ErrGameInitFailed, r)
+				game.CloseWithReason("game crashed")
+				return
+			}
+			failed <- fmt.Errorf("%w: %s", ErrGameInitFailed, errGameFuncReturned)
+			game.Close()
+		}()
+		s.runGameFunc(game, config)
+	}()
+
+	timedOut := make(chan struct{})
+	timer := s.config.Clock.AfterFunc(s.config.GameInitTimeout, func() {
+		close(timedOut)
+	})
+	defer timer.Stop()
+	select {
+	case <-game.started:
+		return nil
+	case <-timedOut:
+		return nil
+	case err := <-failed:
+		// the game loop may have started and finished right away
+		select {
+		case <-game.started:
+			return nil
+		default:
+		}
+		return err
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Package apiclient implements the requests of the CodeGame HTTP API shared by the bot and cgtest packages.
+*/
+package apiclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CreateGame creates a new game on the server at baseURL and returns its ID and join secret.
+func CreateGame(baseURL string, public, protected bool, config any) (string, string, error) {
+	type request struct {
+		Public    bool `json:"public"`
+		Protected bool `json:"protected"`
+		Config    any  `json:"config,omitempty"`
+	}
+	type response struct {
+		GameID     string `json:"game_id"`
+		JoinSecret string `json:"join_secret"`
+	}
+	var res response
+	err := Post(baseURL, "/api/games", request{
+		Public:    public,
+		Protected: protected,
+		Config:    config,
+	}, &res)
+	return res.GameID, res.JoinSecret, err
+}
+
+// CreatePlayer creates a new player in the game and returns its ID and secret.
+func CreatePlayer(baseURL, gameID, username, joinSecret string) (string, string, error) {
+	type request struct {
+		Username   string `json:"username"`
+		JoinSecret string `json:"join_secret,omitempty"`
+	}
+	type response struct {
+		PlayerID     string `json:"player_id"`
+		PlayerSecret string `json:"player_secret"`
+	}
+	var res response
+	err := Post(baseURL, fmt.Sprintf("/api/games/%s/players", url.PathEscape(gameID)), request{
+		Username:   username,
+		JoinSecret: joinSecret,
+	}, &res)
+	return res.PlayerID, res.PlayerSecret, err
+}
+
+// CloseGame closes the game with the admin API of the server.
+func CloseGame(baseURL, gameID, adminToken string) error {
+	path := "/api/admin/games/" + url.PathEscape(gameID)
+	req, err := http.NewRequest(http.MethodDelete, baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkStatus(resp, http.MethodDelete, path)
+}
+
+// ConnectURL returns the websocket URL for connecting a socket to the player.
+func ConnectURL(baseURL, gameID, playerID, playerSecret string) string {
+	return WebsocketURL(baseURL, fmt.Sprintf("/api/games/%s/players/%s/connect?player_secret=%s", url.PathEscape(gameID), url.PathEscape(playerID), url.QueryEscape(playerSecret)))
+}
+
+// SpectateURL returns the websocket URL for spectating the game.
+func SpectateURL(baseURL, gameID string) string {
+	return WebsocketURL(baseURL, fmt.Sprintf("/api/games/%s/spectate", url.PathEscape(gameID)))
+}
+
+// WebsocketURL returns the ws:// or wss:// URL of path on the server at the http:// or https:// baseURL.
+func WebsocketURL(baseURL, path string) string {
+	return "ws" + strings.TrimPrefix(baseURL, "http") + path
+}
+
+// Post sends body encoded as JSON to path and decodes the JSON response into response.
+// A response with a status other than 2xx is returned as an error containing the response body.
+func Post(baseURL, path string, body, response any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(baseURL+path, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	err = checkStatus(resp, http.MethodPost, path)
+	if err != nil {
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+// checkStatus returns an error containing the response body if the status of resp is not 2xx.
+func checkStatus(resp *http.Response, method, path string) error {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, msg)
+	}
+	return nil
+}
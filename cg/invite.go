@@ -0,0 +1,152 @@
+package cg
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// CommandInviteAccept is sent by an invited player to join the game of the invite.
+	CommandInviteAccept CommandName = "cg_invite_accept"
+	// CommandInviteDecline is sent by an invited player to reject the invite.
+	CommandInviteDecline CommandName = "cg_invite_decline"
+
+	EventInvite         EventName = "cg_invite"
+	EventInviteAccepted EventName = "cg_invite_accepted"
+)
+
+// The time after which an unanswered invite expires.
+const inviteLifetime = 10 * time.Minute
+
+var ErrRecipientNotConnected = errors.New("no connected player or room member with this ID")
+
+type InviteCommandData struct {
+	Invite string `json:"invite"`
+}
+
+type InviteEventData struct {
+	Invite string `json:"invite"`
+	GameID string `json:"game_id"`
+}
+
+// InviteAcceptedEventData contains the credentials of the invited player in the new game.
+type InviteAcceptedEventData struct {
+	GameID       string `json:"game_id"`
+	PlayerID     string `json:"player_id"`
+	PlayerSecret string `json:"player_secret"`
+}
+
+type Invite struct {
+	ID string
+	// The ID of the invited player or room member.
+	Recipient string
+	Username  string
+	Game      *Game
+
+	address      string
+	lang         string
+	clientSecret string
+	send         func(event EventName, data any) error
+	createdAt    time.Time
+}
+
+// InvitePlayer sends a cg_invite event to the connected player or room member with the ID identity,
+// who can answer it with the cg_invite_accept or cg_invite_decline command.
+// Game.OnInviteAnswered of game is called with the answer.
+func (s *Server) InvitePlayer(identity string, game *Game) (*Invite, error) {
+	invite := &Invite{
+		ID:        uuid.NewString(),
+		Recipient: identity,
+		Game:      game,
+		createdAt: s.config.Clock.Now(),
+	}
+
+	if player, ok := s.findPlayer(identity); ok && player.SocketCount() > 0 {
+		invite.Username = player.username()
+		invite.address = player.getAddress()
+		invite.lang = player.lang
+		invite.clientSecret = player.clientSecret
+		invite.send = player.Send
+	} else if member, ok := s.findRoomMember(identity); ok && member.connected() {
+		invite.Username = member.username
+		invite.address = member.address
+		invite.lang = member.lang
+		invite.send = member.send
+	} else {
+		return nil, ErrRecipientNotConnected
+	}
+
+	s.invitesLock.Lock()
+	for id, i := range s.invites {
+		if s.config.Clock.Now().Sub(i.createdAt) >= inviteLifetime {
+			delete(s.invites, id)
+		}
+	}
+	s.invites[invite.ID] = invite
+	s.invitesLock.Unlock()
+
+	game.Log.Info("Invited '%s' (%s) to the game.", invite.Username, identity)
+
+	return invite, invite.sendInvite()
+}
+
+// answerInvite handles a cg_invite_accept or cg_invite_decline command of the recipient with the ID identity.
+func (s *Server) answerInvite(identity string, cmd Command) error {
+	var data InviteCommandData
+	err := cmd.UnmarshalData(&data)
+	if err != nil {
+		return err
+	}
+
+	s.invitesLock.Lock()
+	invite, ok := s.invites[data.Invite]
+	if ok && invite.Recipient == identity {
+		delete(s.invites, invite.ID)
+	}
+	s.invitesLock.Unlock()
+	if !ok || invite.Recipient != identity || s.config.Clock.Now().Sub(invite.createdAt) >= inviteLifetime {
+		return errors.New("invite not found")
+	}
+
+	game := invite.Game
+	accepted := cmd.Name == CommandInviteAccept
+	if accepted {
+		if !game.Running() {
+			return ErrGameClosed
+		}
+		playerID, playerSecret, err := game.join(invite.Username, game.joinSecret, invite.address, invite.lang, invite.clientSecret)
+		if err != nil {
+			return err
+		}
+		invite.sendInviteAccepted(playerID, playerSecret)
+	}
+
+	if game.OnInviteAnswered != nil {
+		game.OnInviteAnswered(invite, accepted)
+	}
+	return nil
+}
+
+func (s *Server) findPlayer(playerID string) (*Player, bool) {
+	s.gamesLock.RLock()
+	defer s.gamesLock.RUnlock()
+	for _, g := range s.games {
+		if p, ok := g.GetPlayer(playerID); ok {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+func (s *Server) findRoomMember(memberID string) (*roomMember, bool) {
+	s.roomsLock.RLock()
+	defer s.roomsLock.RUnlock()
+	for _, r := range s.rooms {
+		if m, ok := r.getMember(memberID); ok {
+			return m, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,60 @@
+package cg
+
+import (
+	"net/http"
+	"time"
+)
+
+// JournalEntry describes an event sent to a player.
+type JournalEntry struct {
+	// The number of the event starting with 1 for the first event sent to the player.
+	Seq  uint64    `json:"seq"`
+	Name EventName `json:"name"`
+	Time time.Time `json:"time"`
+	// The number of sockets the event was sent to (0 => stored as missed event).
+	Sockets int `json:"sockets"`
+}
+
+// record adds the event to the journal of the player if EventJournalSize is set.
+func (p *Player) record(event EventName, sockets int) {
+	size := p.server.config.EventJournalSize
+	if size <= 0 {
+		return
+	}
+
+	p.journalLock.Lock()
+	defer p.journalLock.Unlock()
+	p.journalSeq++
+	if len(p.journal) >= size {
+		p.journal = p.journal[1:]
+	}
+	p.journal = append(p.journal, JournalEntry{
+		Seq:     p.journalSeq,
+		Name:    event,
+		Time:    p.server.config.Clock.Now(),
+		Sockets: sockets,
+	})
+}
+
+// Journal returns the most recent events sent to the player, oldest first.
+func (p *Player) Journal() []JournalEntry {
+	p.journalLock.Lock()
+	defer p.journalLock.Unlock()
+	journal := make([]JournalEntry, len(p.journal))
+	copy(journal, p.journal)
+	return journal
+}
+
+func (s *Server) debugJournalEndpoint(w http.ResponseWriter, r *http.Request) {
+	player, ok := s.RequestPlayer(w, r)
+	if !ok {
+		return
+	}
+
+	if s.config.EventJournalSize <= 0 {
+		send(w, http.StatusNotFound, "event journal disabled")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, player.Journal())
+}
@@ -0,0 +1,87 @@
+package cg
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+var (
+	ErrGameNotFound  = errors.New("game not found")
+	ErrGameNotLinked = errors.New("game not linked")
+)
+
+// LinkGame allows the game and the game with gameID to send commands to each other with SendToGame,
+// e.g. to advance the winners of a tournament round.
+func (g *Game) LinkGame(gameID string) error {
+	other, ok := g.server.getGame(gameID)
+	if !ok {
+		return ErrGameNotFound
+	}
+	g.linksLock.Lock()
+	g.links[other.ID] = struct{}{}
+	g.linksLock.Unlock()
+
+	other.linksLock.Lock()
+	other.links[g.ID] = struct{}{}
+	other.linksLock.Unlock()
+	return nil
+}
+
+// UnlinkGame removes the link created with LinkGame.
+func (g *Game) UnlinkGame(gameID string) {
+	g.linksLock.Lock()
+	delete(g.links, gameID)
+	g.linksLock.Unlock()
+
+	if other, ok := g.server.getGame(gameID); ok {
+		other.linksLock.Lock()
+		delete(other.links, g.ID)
+		other.linksLock.Unlock()
+	}
+}
+
+// LinkedGames returns the IDs of the games linked with LinkGame.
+func (g *Game) LinkedGames() []string {
+	g.linksLock.RLock()
+	defer g.linksLock.RUnlock()
+	ids := make([]string, 0, len(g.links))
+	for id := range g.links {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// SendToGame adds a command to the command queue of the linked game with targetGameID.
+// The command has no origin player and the ID of the sending game in SourceGame.
+func (g *Game) SendToGame(targetGameID string, name CommandName, data any) error {
+	g.linksLock.RLock()
+	_, linked := g.links[targetGameID]
+	g.linksLock.RUnlock()
+	if !linked {
+		return ErrGameNotLinked
+	}
+
+	target, ok := g.server.getGame(targetGameID)
+	if !ok {
+		return ErrGameNotFound
+	}
+
+	cmd := Command{
+		Name: name,
+	}
+	var err error
+	cmd.Data, err = json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	g.Log.TraceData(cmd, "Sending '%s' command to game %s.", cmd.Name, target.ID)
+	target.Log.TraceData(cmd, "Received '%s' command from game %s.", cmd.Name, g.ID)
+	if !target.enqueue(CommandWrapper{
+		SourceGame: g.ID,
+		Cmd:        cmd,
+	}) {
+		return ErrGameClosed
+	}
+	return nil
+}
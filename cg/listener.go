@@ -0,0 +1,49 @@
+package cg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Serve serves the API and the frontend on l like Run, e.g. on a listener inherited from a process manager.
+// It returns the error of a failing listener or nil after the server has been shut down with Shutdown.
+func (s *Server) Serve(l net.Listener, runGameFunc func(game *Game, config json.RawMessage)) error {
+	return s.run(context.Background(), runGameFunc, false, l)
+}
+
+// listenTCP creates the listener for TCPPort on the host of ListenAddr or on all interfaces
+// if ListenAddr is empty or a unix socket.
+func (s *Server) listenTCP() (net.Listener, error) {
+	host := ""
+	if s.config.ListenAddr != "" && !strings.HasPrefix(s.config.ListenAddr, "unix://") {
+		var err error
+		host, _, err = net.SplitHostPort(s.config.ListenAddr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid listen address: %w", err)
+		}
+	}
+	return net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(s.config.TCPPort)))
+}
+
+// listen creates the listener for ListenAddr or Port.
+func (s *Server) listen() (net.Listener, error) {
+	if s.config.ListenAddr == "" {
+		return net.Listen("tcp", fmt.Sprintf(":%d", s.config.Port))
+	}
+
+	if strings.HasPrefix(s.config.ListenAddr, "unix://") {
+		path := strings.TrimPrefix(s.config.ListenAddr, "unix://")
+		// remove the socket file left behind by a previous run
+		if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(path)
+		}
+		return net.Listen("unix", path)
+	}
+
+	return net.Listen("tcp", s.config.ListenAddr)
+}
@@ -0,0 +1,61 @@
+package cg
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Locale contains the localized texts of a game for one language.
+type Locale struct {
+	DisplayName string
+	Description string
+	// Messages maps message keys to localized format strings. Keys are used as titles and messages of notifications.
+	Messages map[string]string
+}
+
+// Lang returns the language the player requested when joining (empty => default language).
+func (p *Player) Lang() string {
+	return p.lang
+}
+
+// Localize returns the message with the given key from the locale of the player formatted with a.
+// The key itself is used as the format string if the locale does not contain the message.
+func (p *Player) Localize(key string, a ...any) string {
+	return p.server.localize(p.lang, key, a...)
+}
+
+func (s *Server) localize(lang, key string, a ...any) string {
+	format := key
+	if locale, ok := s.config.Locales[lang]; ok {
+		if msg, ok := locale.Messages[key]; ok {
+			format = msg
+		}
+	}
+	if len(a) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, a...)
+}
+
+func (s *Server) localeNames() []string {
+	names := make([]string, 0, len(s.config.Locales))
+	for name := range s.config.Locales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// localizedInfo returns the display name and description of the game in the language lang.
+func (s *Server) localizedInfo(lang string) (string, string) {
+	displayName, description := s.config.DisplayName, s.config.Description
+	if locale, ok := s.config.Locales[lang]; ok {
+		if locale.DisplayName != "" {
+			displayName = locale.DisplayName
+		}
+		if locale.Description != "" {
+			description = locale.Description
+		}
+	}
+	return displayName, description
+}
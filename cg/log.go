@@ -9,9 +9,33 @@ import (
 )
 
 type debugMessage struct {
-	Severity DebugSeverity   `json:"severity"`
-	Message  string          `json:"message"`
-	Data     json.RawMessage `json:"data,omitempty"`
+	Severity DebugSeverity `json:"severity"`
+	Message  string        `json:"message"`
+	// The name of the event or command the message is about.
+	Name string          `json:"name,omitempty"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// LogSink receives the messages of a Logger, e.g. to write them with a different logging library.
+// Debug sockets receive the messages independently of the sink. Log must be safe for concurrent use.
+type LogSink interface {
+	Log(severity DebugSeverity, message string, data json.RawMessage)
+}
+
+// ConsoleSink prints log messages to the console.
+type ConsoleSink struct{}
+
+func (ConsoleSink) Log(severity DebugSeverity, message string, data json.RawMessage) {
+	switch severity {
+	case DebugTrace:
+		log.Tracef("%s : %s", message, data)
+	case DebugInfo:
+		log.Infof("%s : %s", message, data)
+	case DebugWarning:
+		log.Warnf("%s : %s", message, data)
+	case DebugError:
+		log.Errorf("%s : %s", message, data)
+	}
 }
 
 type Logger struct {
@@ -20,16 +44,29 @@ type Logger struct {
 
 	queue chan debugMessage
 
-	printMessages bool
+	sinkLock sync.RWMutex
+	sink     LogSink
+
+	samplingLock sync.Mutex
+	sampling     int
+	traceCounts  map[string]int
 
-	closed bool
+	closedLock sync.RWMutex
+	closed     bool
 }
 
+// The maximum number of distinct trace messages counted for sampling before the counts are reset.
+const maxSampledMessages = 1024
+
+// NewLogger creates a logger which sends its messages to the connected debug sockets
+// and prints them to the console if printMessages is true.
 func NewLogger(printMessages bool) *Logger {
 	l := &Logger{
-		debugSockets:  make(map[string]*debugSocket),
-		queue:         make(chan debugMessage, 32),
-		printMessages: printMessages,
+		debugSockets: make(map[string]*debugSocket),
+		queue:        make(chan debugMessage, 32),
+	}
+	if printMessages {
+		l.sink = ConsoleSink{}
 	}
 
 	go func() {
@@ -50,6 +87,9 @@ func NewLogger(printMessages bool) *Logger {
 				if active := socket.severities[message.Severity]; !active {
 					continue
 				}
+				if !socket.matchesName(message.Name) {
+					continue
+				}
 				socket.send(data)
 			}
 			l.debugSocketsLock.RUnlock()
@@ -91,8 +131,50 @@ func (l *Logger) ErrorData(data any, format string, a ...any) {
 	l.Log(DebugError, data, format, a...)
 }
 
+// SetTraceSampling makes the logger emit only every n-th trace message with the same format string
+// annotated with the number of skipped messages, e.g. for events sent at a high frequency. (n <= 1 => log all)
+func (l *Logger) SetTraceSampling(n int) {
+	l.samplingLock.Lock()
+	l.sampling = n
+	l.traceCounts = make(map[string]int)
+	l.samplingLock.Unlock()
+}
+
+// sample returns false if the trace message with the format string should be skipped and otherwise the number of skipped similar messages.
+// Keying by the format string instead of the formatted message keeps messages with changing arguments, e.g. IDs, in one count.
+func (l *Logger) sample(format string) (bool, int) {
+	l.samplingLock.Lock()
+	defer l.samplingLock.Unlock()
+	if l.sampling <= 1 {
+		return true, 0
+	}
+	if len(l.traceCounts) >= maxSampledMessages {
+		l.traceCounts = make(map[string]int)
+	}
+	count := l.traceCounts[format]
+	l.traceCounts[format] = count + 1
+	if count%l.sampling != 0 {
+		return false, 0
+	}
+	if count == 0 {
+		return true, 0
+	}
+	return true, l.sampling - 1
+}
+
 func (l *Logger) Log(severity DebugSeverity, data any, format string, a ...any) {
+	skipped := 0
+	if severity == DebugTrace {
+		var ok bool
+		ok, skipped = l.sample(format)
+		if !ok {
+			return
+		}
+	}
 	message := fmt.Sprintf(format, a...)
+	if skipped > 0 {
+		message = fmt.Sprintf("%s (%d similar messages skipped)", message, skipped)
+	}
 	var dataJSON json.RawMessage
 	if data != nil {
 		if d, ok := data.([]byte); ok {
@@ -107,28 +189,32 @@ func (l *Logger) Log(severity DebugSeverity, data any, format string, a ...any)
 		}
 	}
 
-	if l.printMessages {
-		switch severity {
-		case DebugTrace:
-			log.Tracef("%s : %s", message, dataJSON)
-		case DebugInfo:
-			log.Infof("%s : %s", message, dataJSON)
-		case DebugWarning:
-			log.Warnf("%s : %s", message, dataJSON)
-		case DebugError:
-			log.Errorf("%s : %s", message, dataJSON)
-		}
+	l.sinkLock.RLock()
+	sink := l.sink
+	l.sinkLock.RUnlock()
+	if sink != nil {
+		sink.Log(severity, message, dataJSON)
 	}
 
+	l.closedLock.RLock()
+	defer l.closedLock.RUnlock()
 	if !l.closed {
 		l.queue <- debugMessage{
 			Severity: severity,
 			Message:  message,
+			Name:     debugMessageName(data),
 			Data:     dataJSON,
 		}
 	}
 }
 
+// SetSink replaces the destination of the messages besides the debug sockets. (nil => debug sockets only)
+func (l *Logger) SetSink(sink LogSink) {
+	l.sinkLock.Lock()
+	l.sink = sink
+	l.sinkLock.Unlock()
+}
+
 func (l *Logger) addDebugSocket(socket *debugSocket) {
 	l.debugSocketsLock.Lock()
 	l.debugSockets[socket.id] = socket
@@ -150,8 +236,29 @@ func (l *Logger) disconnectDebugSocket(id string) {
 	l.debugSocketsLock.Unlock()
 }
 
+// disconnectDebugSockets disconnects all debug sockets of the logger.
+func (l *Logger) disconnectDebugSockets() {
+	l.debugSocketsLock.Lock()
+	sockets := l.debugSockets
+	l.debugSockets = make(map[string]*debugSocket)
+	l.debugSocketsLock.Unlock()
+
+	for _, socket := range sockets {
+		socket.disconnect()
+	}
+}
+
+// Close disconnects the debug sockets of the logger. Messages logged afterwards are only passed to the sink.
 func (l *Logger) Close() error {
+	l.closedLock.Lock()
+	if l.closed {
+		l.closedLock.Unlock()
+		return nil
+	}
 	l.closed = true
 	close(l.queue)
+	l.closedLock.Unlock()
+
+	l.disconnectDebugSockets()
 	return nil
 }
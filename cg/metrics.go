@@ -0,0 +1,69 @@
+package cg
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// metricsEndpoint writes server metrics in the Prometheus text exposition format.
+func (s *Server) metricsEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	s.gamesLock.RLock()
+	games := make([]*Game, 0, len(s.games))
+	for _, g := range s.games {
+		games = append(games, g)
+	}
+	s.gamesLock.RUnlock()
+
+	fmt.Fprintln(w, "# HELP cg_games The number of running games.")
+	fmt.Fprintln(w, "# TYPE cg_games gauge")
+	fmt.Fprintf(w, "cg_games %d\n", len(games))
+
+	zombies, reaped := s.zombieStats()
+	fmt.Fprintln(w, "# HELP cg_zombie_games The number of games detected as zombies by the last check.")
+	fmt.Fprintln(w, "# TYPE cg_zombie_games gauge")
+	fmt.Fprintf(w, "cg_zombie_games %d\n", zombies)
+
+	fmt.Fprintln(w, "# HELP cg_reaped_games_total The number of zombie games which have been closed.")
+	fmt.Fprintln(w, "# TYPE cg_reaped_games_total counter")
+	fmt.Fprintf(w, "cg_reaped_games_total %d\n", reaped)
+
+	fmt.Fprintln(w, "# HELP cg_game_bytes_sent_total The number of bytes sent to the players and spectators of a game.")
+	fmt.Fprintln(w, "# TYPE cg_game_bytes_sent_total counter")
+	for _, g := range games {
+		fmt.Fprintf(w, "cg_game_bytes_sent_total{game=%q} %d\n", g.ID, g.BytesSent())
+	}
+
+	fmt.Fprintln(w, "# HELP cg_game_bytes_received_total The number of bytes received from the players of a game.")
+	fmt.Fprintln(w, "# TYPE cg_game_bytes_received_total counter")
+	for _, g := range games {
+		fmt.Fprintf(w, "cg_game_bytes_received_total{game=%q} %d\n", g.ID, g.BytesReceived())
+	}
+
+	fmt.Fprintln(w, "# HELP cg_game_missed_events The number of events queued for players of a game without sockets.")
+	fmt.Fprintln(w, "# TYPE cg_game_missed_events gauge")
+	for _, g := range games {
+		fmt.Fprintf(w, "cg_game_missed_events{game=%q} %d\n", g.ID, g.MissedEvents())
+	}
+
+	fmt.Fprintln(w, "# HELP cg_game_replayed_events_total The number of missed events sent to reconnecting players of a game.")
+	fmt.Fprintln(w, "# TYPE cg_game_replayed_events_total counter")
+	for _, g := range games {
+		events, _ := g.ReplayedEvents()
+		fmt.Fprintf(w, "cg_game_replayed_events_total{game=%q} %d\n", g.ID, events)
+	}
+
+	fmt.Fprintln(w, "# HELP cg_game_replayed_bytes_total The number of bytes of the missed events sent to reconnecting players of a game.")
+	fmt.Fprintln(w, "# TYPE cg_game_replayed_bytes_total counter")
+	for _, g := range games {
+		_, bytes := g.ReplayedEvents()
+		fmt.Fprintf(w, "cg_game_replayed_bytes_total{game=%q} %d\n", g.ID, bytes)
+	}
+
+	fmt.Fprintln(w, "# HELP cg_game_reconnects_total The number of sockets players of a game connected after their first one.")
+	fmt.Fprintln(w, "# TYPE cg_game_reconnects_total counter")
+	for _, g := range games {
+		fmt.Fprintf(w, "cg_game_reconnects_total{game=%q} %d\n", g.ID, g.Reconnects())
+	}
+}
@@ -0,0 +1,24 @@
+package cg
+
+// ReachMilestone sends a player_milestone notification to the configured webhooks,
+// e.g. when the player won for the first time or played 100 games.
+// milestone is a short identifier like "first_win" and message is the text posted to chat platforms.
+// The game ID is only included for public games.
+func (p *Player) ReachMilestone(milestone, message string) {
+	p.game.Log.Info("Player '%s' (%s) reached the milestone '%s'.", p.username(), p.ID, milestone)
+
+	var gameID string
+	if p.game.public {
+		gameID = p.game.ID
+	}
+
+	p.server.sendNotification(Notification{
+		Event:     NotificationPlayerMilestone,
+		Game:      p.server.config.Name,
+		GameID:    gameID,
+		Message:   message,
+		Player:    p.username(),
+		Milestone: milestone,
+		Time:      p.server.config.Clock.Now(),
+	})
+}
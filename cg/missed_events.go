@@ -0,0 +1,62 @@
+package cg
+
+import (
+	"encoding/json"
+)
+
+// EventMissedEvents contains all events a player missed while it had no sockets if BatchMissedEvents is enabled.
+const EventMissedEvents EventName = "cg_missed_events"
+
+type MissedEventsEventData struct {
+	Events []Event `json:"events"`
+}
+
+// replayMissedEvents sends the missed events of the player to socket after passing them through
+// Game.MissedEventCoalescer. The caller must hold missedEventsLock.
+func (p *Player) replayMissedEvents(socket *GameSocket) {
+	size := 0
+	for _, data := range p.missedEvents {
+		size += len(data)
+	}
+	p.game.countReplay(len(p.missedEvents), size)
+
+	coalescer := p.game.MissedEventCoalescer
+	if coalescer == nil && !p.server.config.BatchMissedEvents {
+		for _, e := range p.missedEvents {
+			socket.send(e)
+		}
+		return
+	}
+
+	events := make([]Event, 0, len(p.missedEvents))
+	for _, data := range p.missedEvents {
+		var e Event
+		err := json.Unmarshal(data, &e)
+		if err != nil {
+			p.Log.Error("Failed to decode missed event: %s", err)
+			continue
+		}
+		events = append(events, e)
+	}
+
+	if coalescer != nil {
+		events = coalescer(events)
+	}
+
+	if p.server.config.BatchMissedEvents {
+		err := socket.SendMissedEvents(events)
+		if err != nil {
+			p.Log.Error("Failed to send missed events to socket %s: %s", socket.ID, err)
+		}
+		return
+	}
+
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			p.Log.Error("Failed to encode missed '%s' event: %s", e.Name, err)
+			continue
+		}
+		socket.send(data)
+	}
+}
@@ -0,0 +1,201 @@
+package cg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Bananenpro/log"
+)
+
+var gameNameRegex = regexp.MustCompile("^[a-z0-9_]+$")
+
+// MultiServer hosts several game types in one process. Each game type is a separate Server with its own
+// CGE file, runGameFunc and limits. The API of a game type is served under /api/{gameName}/... and its frontend under /{gameName}/.
+// GET /api lists all game types.
+type MultiServer struct {
+	lock    sync.RWMutex
+	servers map[string]*Server
+	// handlers by game name
+	handlers map[string]http.Handler
+}
+
+func NewMultiServer() *MultiServer {
+	return &MultiServer{
+		servers:  make(map[string]*Server),
+		handlers: make(map[string]http.Handler),
+	}
+}
+
+// AddGame registers a game type. name must be snake_case and is used as the route prefix.
+// runGameFunc is called in a new goroutine for every created game of this type.
+func (m *MultiServer) AddGame(name string, config ServerConfig, runGameFunc func(game *Game, config json.RawMessage)) (*Server, error) {
+	if !gameNameRegex.MatchString(name) || name == "api" {
+		return nil, fmt.Errorf("invalid game name: %s", name)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.servers[name]; ok {
+		return nil, fmt.Errorf("game '%s' already registered", name)
+	}
+
+	server := NewServer(name, config)
+	server.apiPath = "/api/" + name
+	err := server.ValidateEvents()
+	if err != nil {
+		return nil, err
+	}
+	m.servers[name] = server
+	m.handlers[name] = server.Handler(runGameFunc)
+	return server, nil
+}
+
+// Server returns the server of the game type with the name.
+func (m *MultiServer) Server(name string) (*Server, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	server, ok := m.servers[name]
+	return server, ok
+}
+
+// Handler returns the HTTP handler serving all game types without starting a webserver.
+func (m *MultiServer) Handler() http.Handler {
+	return http.HandlerFunc(m.serveHTTP)
+}
+
+// Run starts the webserver for all game types on port and listens for new connections.
+// It exits the process if a listener fails.
+func (m *MultiServer) Run(port int) {
+	err := m.RunContext(context.Background(), port)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// RunContext starts the webserver like Run but returns the error of a failing listener instead of exiting the process.
+// The TCP listeners of the game types with a TCPPort are started as well.
+// All game types are shut down with Shutdown when ctx is done or a listener fails.
+func (m *MultiServer) RunContext(ctx context.Context, port int) error {
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+
+	m.lock.RLock()
+	servers := make([]*Server, 0, len(m.servers))
+	for _, s := range m.servers {
+		servers = append(servers, s)
+	}
+	m.lock.RUnlock()
+
+	errs := make(chan error, len(servers)+1)
+	for _, s := range servers {
+		err = s.startTCP(nil, errs)
+		if err != nil {
+			l.Close()
+			m.shutdown(servers)
+			return err
+		}
+	}
+
+	httpServer := &http.Server{
+		Handler: m.Handler(),
+	}
+	for _, s := range servers {
+		s.StartAutostartGames()
+		s.notify(NotificationServerStarted, "", "The server is now online.")
+	}
+	log.Infof("Listening on %s...", l.Addr())
+	go func() {
+		err := httpServer.Serve(l)
+		if !errors.Is(err, http.ErrServerClosed) {
+			errs <- err
+		}
+	}()
+
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), defaultShutdownTimeout)
+	defer cancel()
+	httpServer.Shutdown(shutdownCtx)
+	m.shutdown(servers)
+	return err
+}
+
+// shutdown shuts down all servers which have not been shut down yet, each bounded by its ShutdownTimeout.
+func (m *MultiServer) shutdown(servers []*Server) {
+	for _, s := range servers {
+		if !s.closed() {
+			s.shutdownWithTimeout()
+		}
+	}
+}
+
+func (m *MultiServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	if path == "/api" || path == "/api/" {
+		m.gamesEndpoint(w, r)
+		return
+	}
+
+	prefix := ""
+	rest := strings.TrimPrefix(path, "/")
+	if strings.HasPrefix(path, "/api/") {
+		prefix = "/api"
+		rest = strings.TrimPrefix(path, "/api/")
+	}
+	name, rest, _ := strings.Cut(rest, "/")
+
+	m.lock.RLock()
+	handler, ok := m.handlers[name]
+	m.lock.RUnlock()
+	if !ok {
+		send(w, http.StatusNotFound, "game not found")
+		return
+	}
+
+	r2 := new(http.Request)
+	*r2 = *r
+	u := *r.URL
+	u.Path = prefix + "/" + rest
+	u.RawPath = ""
+	r2.URL = &u
+	handler.ServeHTTP(w, r2)
+}
+
+func (m *MultiServer) gamesEndpoint(w http.ResponseWriter, r *http.Request) {
+	type game struct {
+		Name        string `json:"name"`
+		DisplayName string `json:"display_name,omitempty"`
+		Description string `json:"description,omitempty"`
+		Version     string `json:"version,omitempty"`
+	}
+
+	m.lock.RLock()
+	games := make([]game, 0, len(m.servers))
+	for name, s := range m.servers {
+		games = append(games, game{
+			Name:        name,
+			DisplayName: s.config.DisplayName,
+			Description: s.config.Description,
+			Version:     s.config.Version,
+		})
+	}
+	m.lock.RUnlock()
+
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].Name < games[j].Name
+	})
+	sendJSON(w, http.StatusOK, games)
+}
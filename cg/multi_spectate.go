@@ -0,0 +1,155 @@
+package cg
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+const (
+	// CommandSpectate is sent by sockets connected to /api/spectate to start spectating a game.
+	CommandSpectate CommandName = "cg_spectate"
+	// CommandUnspectate is sent by sockets connected to /api/spectate to stop spectating a game.
+	CommandUnspectate CommandName = "cg_unspectate"
+)
+
+type SpectateCommandData struct {
+	GameID string `json:"game_id"`
+}
+
+// multiSpectateEndpoint upgrades to a socket which spectates the games selected with cg_spectate.
+// Events sent to the socket contain the ID of their game in the game field.
+func (s *Server) multiSpectateEndpoint(w http.ResponseWriter, r *http.Request) {
+	if !checkClientVersion(w, r) {
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	socket := s.newGameSocket(conn, r.RemoteAddr, r.UserAgent())
+	socket.spectating = make(map[string]*Game)
+	socket.useSendQueue()
+
+	s.multiSpectatorsLock.Lock()
+	s.multiSpectators[socket.ID] = socket
+	s.multiSpectatorsLock.Unlock()
+
+	s.log.TraceData(socket.info(), "New multi-game spectator socket connected with id %s.", socket.ID)
+
+	go socket.handleConnection()
+}
+
+func (s *Server) removeMultiSpectator(id string) {
+	s.multiSpectatorsLock.Lock()
+	delete(s.multiSpectators, id)
+	s.multiSpectatorsLock.Unlock()
+}
+
+// disconnectMultiSpectators stops all sockets connected to /api/spectate from spectating and disconnects them.
+func (s *Server) disconnectMultiSpectators() {
+	s.multiSpectatorsLock.Lock()
+	sockets := s.multiSpectators
+	s.multiSpectators = make(map[string]*GameSocket)
+	s.multiSpectatorsLock.Unlock()
+
+	var disconnects sync.WaitGroup
+	for _, socket := range sockets {
+		socket.unspectateAll()
+		disconnects.Add(1)
+		go func(socket *GameSocket) {
+			defer disconnects.Done()
+			socket.disconnect()
+		}(socket)
+	}
+	disconnects.Wait()
+}
+
+func (s *GameSocket) isMultiSpectator() bool {
+	return s.spectating != nil
+}
+
+func (s *GameSocket) handleSpectateCommand(cmd Command) error {
+	var data SpectateCommandData
+	err := cmd.UnmarshalData(&data)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Name == CommandUnspectate {
+		s.spectatingLock.Lock()
+		game, ok := s.spectating[data.GameID]
+		delete(s.spectating, data.GameID)
+		s.spectatingLock.Unlock()
+		if ok {
+			game.removeSpectator(s.ID)
+		}
+		return nil
+	}
+
+	game, ok := s.server.getGame(data.GameID)
+	if !ok {
+		return ErrGameNotFound
+	}
+
+	s.spectatingLock.Lock()
+	_, ok = s.spectating[game.ID]
+	s.spectatingLock.Unlock()
+	if ok {
+		return nil
+	}
+
+	err = game.addSpectator(s)
+	if err != nil {
+		return err
+	}
+
+	s.spectatingLock.Lock()
+	s.spectating[game.ID] = game
+	s.spectatingLock.Unlock()
+	return nil
+}
+
+func (s *GameSocket) unspectateAll() {
+	s.spectatingLock.Lock()
+	games := s.spectating
+	s.spectating = make(map[string]*Game)
+	s.spectatingLock.Unlock()
+	for _, g := range games {
+		g.removeSpectator(s.ID)
+	}
+}
+
+// sendToSpectator sends an event of the game to the spectator socket. encoded is the JSON encoding of e.
+// Events sent to multi-game spectators are tagged with the ID of the game.
+func (g *Game) sendToSpectator(s *GameSocket, e Event, encoded []byte) error {
+	if !s.isMultiSpectator() {
+		return s.sendEvent(e.Name, encoded)
+	}
+
+	e.Game = g.ID
+	tagged, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.sendEvent(e.Name, tagged)
+}
+
+// sendDataToSpectator encodes data as the event and sends it to the spectator socket like sendToSpectator.
+func (g *Game) sendDataToSpectator(s *GameSocket, event EventName, data any) error {
+	e := Event{
+		Name: event,
+	}
+	err := e.marshalData(data)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return g.sendToSpectator(s, e, encoded)
+}
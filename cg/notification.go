@@ -0,0 +1,74 @@
+package cg
+
+// EventNotification is a standard event for messages which frontends display to the user, e.g. as a toast.
+const EventNotification EventName = "cg_notification"
+
+type NotificationLevel string
+
+const (
+	NotificationInfo    NotificationLevel = "info"
+	NotificationWarning NotificationLevel = "warning"
+	NotificationError   NotificationLevel = "error"
+)
+
+type NotificationEventData struct {
+	Level   NotificationLevel `json:"level"`
+	Title   string            `json:"title,omitempty"`
+	Message string            `json:"message"`
+	// The time in milliseconds after which the notification should be hidden (0 => until dismissed).
+	TTL int64 `json:"ttl,omitempty"`
+}
+
+// Notify sends a cg_notification event to all players and spectators.
+func (g *Game) Notify(level NotificationLevel, message string) error {
+	return g.SendNotification(NotificationEventData{
+		Level:   level,
+		Message: message,
+	})
+}
+
+// SendNotification sends a cg_notification event with an optional title and TTL to all players and spectators.
+// The title and message are localized for every player with Player.Localize.
+func (g *Game) SendNotification(notification NotificationEventData) error {
+	if len(g.server.config.Locales) == 0 {
+		return g.Send(EventNotification, notification)
+	}
+
+	var sendErr error
+
+	g.playersLock.RLock()
+	for _, p := range g.players {
+		if err := p.SendNotification(notification); err != nil {
+			sendErr = err
+		}
+	}
+	g.playersLock.RUnlock()
+
+	g.spectatorsLock.RLock()
+	for _, s := range g.spectators {
+		if err := g.sendDataToSpectator(s, EventNotification, notification); err != nil {
+			sendErr = err
+		}
+	}
+	g.spectatorsLock.RUnlock()
+
+	return sendErr
+}
+
+// Notify sends a cg_notification event to the player.
+func (p *Player) Notify(level NotificationLevel, message string) error {
+	return p.SendNotification(NotificationEventData{
+		Level:   level,
+		Message: message,
+	})
+}
+
+// SendNotification sends a cg_notification event with an optional title and TTL to the player.
+// The title and message are localized with Player.Localize.
+func (p *Player) SendNotification(notification NotificationEventData) error {
+	if notification.Title != "" {
+		notification.Title = p.Localize(notification.Title)
+	}
+	notification.Message = p.Localize(notification.Message)
+	return p.Send(EventNotification, notification)
+}
@@ -0,0 +1,131 @@
+package cg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type NotificationEvent string
+
+const (
+	NotificationServerStarted NotificationEvent = "server_started"
+	NotificationGameCreated   NotificationEvent = "game_created"
+	NotificationGameClosed    NotificationEvent = "game_closed"
+	// A player reached a milestone reported with Player.ReachMilestone.
+	NotificationPlayerMilestone NotificationEvent = "player_milestone"
+)
+
+type NotificationFormat string
+
+const (
+	// The notification is sent as a JSON encoded Notification object.
+	NotificationFormatJSON NotificationFormat = "json"
+	// The notification is sent as a Discord webhook message.
+	NotificationFormatDiscord NotificationFormat = "discord"
+)
+
+type NotificationConfig struct {
+	// The URL the notifications are posted to.
+	WebhookURL string
+	// The format of the request body. (default: NotificationFormatJSON)
+	Format NotificationFormat
+	// The events which trigger a notification (empty => all).
+	Events []NotificationEvent
+}
+
+type Notification struct {
+	Event   NotificationEvent `json:"event"`
+	Game    string            `json:"game"`
+	GameID  string            `json:"game_id,omitempty"`
+	Message string            `json:"message"`
+	// The username of the player who reached a milestone.
+	Player string `json:"player,omitempty"`
+	// The name of the reached milestone, e.g. "first_win".
+	Milestone string    `json:"milestone,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+var notificationClient = &http.Client{
+	Timeout: 10 * time.Second,
+}
+
+func (s *Server) notify(event NotificationEvent, gameID string, format string, a ...any) {
+	if len(s.config.Notifications) == 0 {
+		return
+	}
+
+	s.sendNotification(Notification{
+		Event:   event,
+		Game:    s.config.Name,
+		GameID:  gameID,
+		Message: fmt.Sprintf(format, a...),
+		Time:    s.config.Clock.Now(),
+	})
+}
+
+func (s *Server) sendNotification(notification Notification) {
+	if len(s.config.Notifications) == 0 {
+		return
+	}
+
+	for _, config := range s.config.Notifications {
+		if !config.wants(notification.Event) {
+			continue
+		}
+		go func(config NotificationConfig) {
+			err := config.post(s, notification)
+			if err != nil {
+				s.log.Error("Failed to send '%s' notification: %s", notification.Event, err)
+			}
+		}(config)
+	}
+}
+
+func (c NotificationConfig) wants(event NotificationEvent) bool {
+	if len(c.Events) == 0 {
+		return true
+	}
+	for _, e := range c.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (c NotificationConfig) post(server *Server, notification Notification) error {
+	var body any
+	switch c.Format {
+	case NotificationFormatDiscord:
+		name := server.config.DisplayName
+		if name == "" {
+			name = server.config.Name
+		}
+		body = struct {
+			Content string `json:"content"`
+		}{
+			Content: fmt.Sprintf("**%s**: %s", name, notification.Message),
+		}
+	default:
+		body = notification
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := notificationClient.Post(c.WebhookURL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
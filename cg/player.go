@@ -8,6 +8,16 @@ import (
 	"time"
 )
 
+// SocketPolicy decides what happens when a player who already has MaxSocketsPerPlayer sockets connects another one.
+type SocketPolicy int
+
+const (
+	// The new socket is rejected.
+	RejectNewSocket SocketPolicy = iota
+	// The oldest socket of the player is disconnected to make room for the new one.
+	EvictOldestSocket
+)
+
 type Player struct {
 	ID       string
 	Username string
@@ -18,13 +28,52 @@ type Player struct {
 	game   *Game
 	server *Server
 
+	// guards Username and address, which are scrubbed by ForgetPlayer
+	infoLock sync.RWMutex
+	address  string
+	lang     string
+	// the hash of the secret of the client which joined as this player, see /api/players/sessions
+	clientSecret string
+	strikes      int
+	joinedAt     time.Time
+	// guarded by the playersLock of the game
+	left bool
+	// the index of the assigned color or avatar, see Color
+	colorIndex int
+
 	socketsLock    sync.RWMutex
 	sockets        map[string]*GameSocket
 	socketCount    int
+	connections    int
 	lastConnection time.Time
+	offline        bool
+	graceTimer     Timer
 
 	missedEventsLock sync.RWMutex
 	missedEvents     [][]byte
+
+	timersLock sync.RWMutex
+	timers     map[string]*PlayerTimer
+
+	bandwidth bandwidth
+	quality   connectionQuality
+
+	journalLock sync.Mutex
+	journal     []JournalEntry
+	journalSeq  uint64
+}
+
+// username returns the username of the player. It is empty after the player has been forgotten.
+func (p *Player) username() string {
+	p.infoLock.RLock()
+	defer p.infoLock.RUnlock()
+	return p.Username
+}
+
+func (p *Player) getAddress() string {
+	p.infoLock.RLock()
+	defer p.infoLock.RUnlock()
+	return p.address
 }
 
 // Send sends the event to all sockets currently connected to the player.
@@ -46,27 +95,31 @@ func (p *Player) Send(event EventName, data any) error {
 
 	p.Log.TraceData(e, "Sending '%s' event...", e.Name)
 
-	p.sendEncoded(jsonData)
+	p.sendEncoded(e.Name, jsonData)
 	return nil
 }
 
-func (p *Player) sendEncoded(data []byte) error {
+func (p *Player) sendEncoded(event EventName, data []byte) error {
 	p.socketsLock.RLock()
 	defer p.socketsLock.RUnlock()
+	var sendErr error
 	for _, socket := range p.sockets {
-		err := socket.send(data)
+		err := socket.sendEvent(event, data)
 		if err != nil {
-			return err
+			p.Log.Trace("Failed to send '%s' event to socket %s: %s", event, socket.ID, err)
+			sendErr = err
 		}
 	}
 
+	p.record(event, len(p.sockets))
+
 	if len(p.sockets) == 0 {
 		p.missedEventsLock.Lock()
 		p.missedEvents = append(p.missedEvents, data)
 		p.missedEventsLock.Unlock()
 	}
 
-	return nil
+	return sendErr
 }
 
 // Leave leaves the game.
@@ -86,36 +139,113 @@ func (p *Player) handleCommand(cmd Command) error {
 	if p.game == nil {
 		return fmt.Errorf("unexpected command: %s", cmd.Name)
 	}
-	p.game.cmdChan <- CommandWrapper{
+	if cmd.Name == CommandVote {
+		return p.game.handleVote(p, cmd)
+	}
+	if cmd.Name == CommandUpdateSettings {
+		return p.game.UpdateSettings(p, cmd.Data)
+	}
+	if cmd.Name == CommandClosingAck {
+		return p.game.handleClosingAck(p)
+	}
+	if cmd.Name == CommandInviteAccept || cmd.Name == CommandInviteDecline {
+		return p.server.answerInvite(p.ID, cmd)
+	}
+	span := p.traceCommand(cmd)
+	if err := p.game.validateCommand(p, cmd); err != nil {
+		span.SetError(err)
+		span.End()
+		return err
+	}
+	if !p.game.enqueue(CommandWrapper{
 		Origin: p,
 		Cmd:    cmd,
+		span:   span,
+	}) {
+		span.End()
+		return NewError(ErrorGameClosed, "game closed")
 	}
 	return nil
 }
 
 func (p *Player) addSocket(socket *GameSocket) error {
-	if p.server.config.MaxSocketsPerPlayer > 0 && p.SocketCount() >= p.server.config.MaxSocketsPerPlayer {
+	if p.socketLimitReached() {
 		return errors.New("max socket count reached for this player")
 	}
+	for p.server.config.MaxSocketsPerPlayer > 0 && p.SocketCount() >= p.server.config.MaxSocketsPerPlayer {
+		p.evictOldestSocket()
+	}
 
 	socket.player = p
 
 	p.socketsLock.Lock()
 	p.sockets[socket.ID] = socket
 	p.socketCount++
+	reconnect := p.connections > 0
+	p.connections++
+	p.offline = false
+	if p.graceTimer != nil {
+		p.graceTimer.Stop()
+		p.graceTimer = nil
+	}
+	// sent while holding the lock so that no event can precede it
+	socket.sendAuthenticated()
 	p.socketsLock.Unlock()
 
+	if reconnect {
+		p.quality.addReconnect(p.server.config.Clock.Now())
+		p.game.countReconnect()
+	}
+
+	if reconnect && p.game.OnResyncRequest != nil {
+		p.resync(socket)
+		return nil
+	}
+
 	p.missedEventsLock.Lock()
 	if len(p.missedEvents) > 0 {
-		for _, e := range p.missedEvents {
-			socket.send(e)
-		}
+		p.replayMissedEvents(socket)
 		p.missedEvents = make([][]byte, 0)
 	}
 	p.missedEventsLock.Unlock()
 	return nil
 }
 
+// socketLimitReached returns true if a new socket would be rejected because of MaxSocketsPerPlayer.
+func (p *Player) socketLimitReached() bool {
+	return p.server.config.SingleSocketPolicy == RejectNewSocket &&
+		p.server.config.MaxSocketsPerPlayer > 0 && p.SocketCount() >= p.server.config.MaxSocketsPerPlayer
+}
+
+// evictOldestSocket disconnects the socket of the player which has been connected the longest.
+func (p *Player) evictOldestSocket() {
+	p.socketsLock.RLock()
+	var oldest *GameSocket
+	for _, s := range p.sockets {
+		if oldest == nil || s.connectedAt.Before(oldest.connectedAt) {
+			oldest = s
+		}
+	}
+	p.socketsLock.RUnlock()
+
+	if oldest != nil {
+		p.Log.Info("Evicting socket %s in favor of a new socket.", oldest.ID)
+		p.disconnectSocket(oldest.ID)
+	}
+}
+
+// resync replaces the missed events with a cg_resync event containing the snapshot provided by the game.
+func (p *Player) resync(socket *GameSocket) {
+	p.missedEventsLock.Lock()
+	p.missedEvents = make([][]byte, 0)
+	p.missedEventsLock.Unlock()
+
+	err := socket.SendResync(p.game.OnResyncRequest(p))
+	if err != nil {
+		p.Log.Error("Failed to send resync snapshot to socket %s: %s", socket.ID, err)
+	}
+}
+
 func (p *Player) disconnectSocket(id string) {
 	p.socketsLock.Lock()
 
@@ -123,8 +253,52 @@ func (p *Player) disconnectSocket(id string) {
 		socket.disconnect()
 		delete(p.sockets, id)
 		p.socketCount--
-		p.lastConnection = time.Now()
+		p.lastConnection = p.server.config.Clock.Now()
+		if p.socketCount == 0 {
+			p.startGracePeriod()
+		}
 	}
 
 	p.socketsLock.Unlock()
 }
+
+// Online returns true if the player has a connected socket or lost its last socket less than ReconnectGracePeriod ago.
+func (p *Player) Online() bool {
+	p.socketsLock.RLock()
+	defer p.socketsLock.RUnlock()
+	return !p.offline
+}
+
+// startGracePeriod marks the player as disconnected after ReconnectGracePeriod unless a socket reconnects.
+// The caller must hold socketsLock.
+func (p *Player) startGracePeriod() {
+	grace := p.server.config.ReconnectGracePeriod
+	if grace <= 0 {
+		go p.markOffline(p.connections)
+		return
+	}
+	connections := p.connections
+	p.graceTimer = p.server.config.Clock.AfterFunc(grace, func() {
+		p.markOffline(connections)
+	})
+}
+
+func (p *Player) markOffline(connections int) {
+	p.socketsLock.Lock()
+	if p.socketCount > 0 || p.connections != connections || p.offline {
+		p.socketsLock.Unlock()
+		return
+	}
+	p.offline = true
+	p.socketsLock.Unlock()
+
+	if _, ok := p.game.GetPlayer(p.ID); !ok {
+		return
+	}
+
+	p.game.Log.Trace("Player '%s' (%s) disconnected.", p.username(), p.ID)
+	p.game.SendPlayerDisconnected(p)
+	if p.game.OnPlayerDisconnected != nil {
+		p.game.OnPlayerDisconnected(p)
+	}
+}
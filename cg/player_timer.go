@@ -0,0 +1,229 @@
+package cg
+
+import (
+	"sync"
+	"time"
+)
+
+// EventTimer is sent to a player whenever the state of one of its timers changes.
+const EventTimer EventName = "cg_timer"
+
+type TimerState string
+
+const (
+	TimerRunning   TimerState = "running"
+	TimerPaused    TimerState = "paused"
+	TimerCancelled TimerState = "cancelled"
+	TimerExpired   TimerState = "expired"
+)
+
+type TimerEventData struct {
+	Name  string     `json:"name"`
+	State TimerState `json:"state"`
+	// The remaining time in milliseconds.
+	Remaining int64 `json:"remaining"`
+}
+
+// PlayerTimer is a named countdown of a player, e.g. a chess clock.
+type PlayerTimer struct {
+	Name string
+
+	player   *Player
+	onExpire func()
+
+	lock      sync.Mutex
+	state     TimerState
+	remaining time.Duration
+	startedAt time.Time
+	timer     Timer
+}
+
+// StartTimer starts a countdown of d for the player which is announced to the player with cg_timer events.
+// onExpire is executed on the goroutine consuming the command queue like functions passed to Game.Schedule.
+// A running timer with the same name is cancelled.
+func (p *Player) StartTimer(name string, d time.Duration, onExpire func()) *PlayerTimer {
+	t := &PlayerTimer{
+		Name:      name,
+		player:    p,
+		onExpire:  onExpire,
+		state:     TimerPaused,
+		remaining: d,
+	}
+
+	p.timersLock.Lock()
+	old := p.timers[name]
+	p.timers[name] = t
+	p.timersLock.Unlock()
+
+	if old != nil {
+		old.Cancel()
+	}
+
+	t.Resume()
+	return t
+}
+
+// Timer returns the timer of the player with the given name.
+func (p *Player) Timer(name string) (*PlayerTimer, bool) {
+	p.timersLock.RLock()
+	defer p.timersLock.RUnlock()
+	t, ok := p.timers[name]
+	return t, ok
+}
+
+// PauseTimers pauses all running timers of all players in the game.
+func (g *Game) PauseTimers() {
+	for _, t := range g.playerTimers() {
+		t.Pause()
+	}
+}
+
+// ResumeTimers resumes all paused timers of all players in the game.
+func (g *Game) ResumeTimers() {
+	for _, t := range g.playerTimers() {
+		t.Resume()
+	}
+}
+
+func (g *Game) playerTimers() []*PlayerTimer {
+	g.playersLock.RLock()
+	defer g.playersLock.RUnlock()
+	timers := make([]*PlayerTimer, 0)
+	for _, p := range g.players {
+		p.timersLock.RLock()
+		for _, t := range p.timers {
+			timers = append(timers, t)
+		}
+		p.timersLock.RUnlock()
+	}
+	return timers
+}
+
+// Remaining returns the remaining time of the timer.
+func (t *PlayerTimer) Remaining() time.Duration {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.remainingLocked()
+}
+
+// State returns the state of the timer.
+func (t *PlayerTimer) State() TimerState {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.state
+}
+
+// Pause stops the countdown until Resume is called.
+func (t *PlayerTimer) Pause() {
+	t.lock.Lock()
+	if t.state != TimerRunning {
+		t.lock.Unlock()
+		return
+	}
+	t.remaining = t.remainingLocked()
+	t.timer.Stop()
+	t.state = TimerPaused
+	t.lock.Unlock()
+	t.announce()
+}
+
+// Resume continues a paused countdown.
+func (t *PlayerTimer) Resume() {
+	t.lock.Lock()
+	if t.state != TimerPaused {
+		t.lock.Unlock()
+		return
+	}
+	t.start()
+	t.lock.Unlock()
+	t.announce()
+}
+
+// Extend adds d to the remaining time of the timer.
+func (t *PlayerTimer) Extend(d time.Duration) {
+	t.lock.Lock()
+	switch t.state {
+	case TimerPaused:
+		t.remaining += d
+	case TimerRunning:
+		t.timer.Stop()
+		t.remaining = t.remainingLocked() + d
+		t.start()
+	default:
+		t.lock.Unlock()
+		return
+	}
+	t.lock.Unlock()
+	t.announce()
+}
+
+// Cancel stops the timer without calling onExpire.
+func (t *PlayerTimer) Cancel() {
+	t.lock.Lock()
+	if t.state == TimerCancelled || t.state == TimerExpired {
+		t.lock.Unlock()
+		return
+	}
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	t.remaining = t.remainingLocked()
+	t.state = TimerCancelled
+	t.lock.Unlock()
+	t.remove()
+	t.announce()
+}
+
+// start starts the countdown. The caller must hold t.lock.
+func (t *PlayerTimer) start() {
+	clock := t.player.server.config.Clock
+	t.state = TimerRunning
+	t.startedAt = clock.Now()
+	t.timer = clock.AfterFunc(t.remaining, t.expire)
+}
+
+func (t *PlayerTimer) expire() {
+	t.lock.Lock()
+	if t.state != TimerRunning || t.remainingLocked() > 0 {
+		t.lock.Unlock()
+		return
+	}
+	t.state = TimerExpired
+	t.remaining = 0
+	t.lock.Unlock()
+
+	t.remove()
+	t.announce()
+	if t.onExpire != nil {
+		t.player.game.enqueue(CommandWrapper{
+			scheduled: t.onExpire,
+		})
+	}
+}
+
+func (t *PlayerTimer) remainingLocked() time.Duration {
+	if t.state != TimerRunning {
+		return t.remaining
+	}
+	remaining := t.remaining - t.player.server.config.Clock.Now().Sub(t.startedAt)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+func (t *PlayerTimer) remove() {
+	t.player.timersLock.Lock()
+	if t.player.timers[t.Name] == t {
+		delete(t.player.timers, t.Name)
+	}
+	t.player.timersLock.Unlock()
+}
+
+func (t *PlayerTimer) announce() {
+	t.lock.Lock()
+	state := t.state
+	remaining := t.remainingLocked()
+	t.lock.Unlock()
+	t.player.SendTimer(t.Name, state, remaining)
+}
@@ -0,0 +1,60 @@
+package cg
+
+import (
+	"errors"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+)
+
+// The path the profiles are served at by default and the prefix expected by pprof.Index.
+const defaultPprofPath = "/debug/pprof"
+
+// pprofHandler serves the runtime profiles of net/http/pprof at PprofPath.
+func (s *Server) pprofHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(defaultPprofPath+"/", pprof.Index)
+	mux.HandleFunc(defaultPprofPath+"/cmdline", pprof.Cmdline)
+	mux.HandleFunc(defaultPprofPath+"/profile", pprof.Profile)
+	mux.HandleFunc(defaultPprofPath+"/symbol", pprof.Symbol)
+	mux.HandleFunc(defaultPprofPath+"/trace", pprof.Trace)
+
+	if s.config.PprofPath == defaultPprofPath {
+		return mux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, s.config.PprofPath) {
+			http.NotFound(w, r)
+			return
+		}
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = defaultPprofPath + strings.TrimPrefix(r.URL.Path, s.config.PprofPath)
+		r2.URL.RawPath = ""
+		mux.ServeHTTP(w, r2)
+	})
+}
+
+// startPprofServer serves the profiles on PprofAddr until Shutdown is called.
+// The profiles are not essential for the game server, so an error of the listener is only logged.
+func (s *Server) startPprofServer() {
+	server := &http.Server{
+		Addr:    s.config.PprofAddr,
+		Handler: s.pprofHandler(),
+	}
+	s.listenersLock.Lock()
+	// Shutdown only closes the pprof server if it has been registered
+	if s.closed() {
+		s.listenersLock.Unlock()
+		return
+	}
+	s.pprofServer = server
+	s.listenersLock.Unlock()
+
+	s.log.Info("Serving profiles on %s%s...", s.config.PprofAddr, s.config.PprofPath)
+	go func() {
+		err := server.ListenAndServe()
+		if !errors.Is(err, http.ErrServerClosed) {
+			s.log.Error("Failed to serve profiles on %s: %s", s.config.PprofAddr, err)
+		}
+	}()
+}
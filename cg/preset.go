@@ -0,0 +1,57 @@
+package cg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// Preset is a named game config which clients can reference when creating a game.
+type Preset struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Config      json.RawMessage `json:"config"`
+}
+
+// RegisterPreset registers a named game config, e.g. "blitz" or "classic", which is listed by GET /api/presets
+// and can be referenced with the preset field of POST /api/games instead of sending the config.
+// Registering a preset with an existing name replaces it.
+func (s *Server) RegisterPreset(name, description string, config any) error {
+	if name == "" {
+		return fmt.Errorf("empty preset name")
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return ErrEncodeFailed
+	}
+	s.presetsLock.Lock()
+	s.presets[name] = Preset{
+		Name:        name,
+		Description: description,
+		Config:      data,
+	}
+	s.presetsLock.Unlock()
+	return nil
+}
+
+// getPreset returns the config of the preset with the name.
+func (s *Server) getPreset(name string) (json.RawMessage, bool) {
+	s.presetsLock.RLock()
+	defer s.presetsLock.RUnlock()
+	preset, ok := s.presets[name]
+	return preset.Config, ok
+}
+
+func (s *Server) presetsEndpoint(w http.ResponseWriter, r *http.Request) {
+	s.presetsLock.RLock()
+	presets := make([]Preset, 0, len(s.presets))
+	for _, p := range s.presets {
+		presets = append(presets, p)
+	}
+	s.presetsLock.RUnlock()
+	sort.Slice(presets, func(i, j int) bool {
+		return presets[i].Name < presets[j].Name
+	})
+	sendJSON(w, http.StatusOK, presets)
+}
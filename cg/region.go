@@ -0,0 +1,35 @@
+package cg
+
+import "net/http"
+
+// SetRegion tags the game with a region, e.g. "eu-west", for clients choosing a game close to them.
+// Games without a region belong to the Region of the server.
+func (g *Game) SetRegion(region string) {
+	g.configLock.Lock()
+	g.region = region
+	g.configLock.Unlock()
+}
+
+// Region returns the region set with SetRegion or the Region of the server.
+func (g *Game) Region() string {
+	g.configLock.RLock()
+	defer g.configLock.RUnlock()
+	if g.region == "" {
+		return g.server.config.Region
+	}
+	return g.region
+}
+
+// pingEndpoint returns the server time for clients measuring their latency to the server, e.g. to pick the closest instance.
+func (s *Server) pingEndpoint(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		// The server time in unix milliseconds.
+		Time   int64  `json:"time"`
+		Region string `json:"region,omitempty"`
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	sendJSON(w, http.StatusOK, response{
+		Time:   s.config.Clock.Now().UnixMilli(),
+		Region: s.config.Region,
+	})
+}
@@ -0,0 +1,80 @@
+package cg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// The maximum size of JSON request bodies accepted by the API endpoints. (1 MiB)
+const DefaultMaxBodySize = 1 << 20
+
+// RequestError is returned by DecodeJSONBody with the status code and message which have been sent to the client.
+type RequestError struct {
+	Status  int
+	Message string
+}
+
+func (e *RequestError) Error() string {
+	return e.Message
+}
+
+// DecodeJSONBody decodes the JSON body of r into dst, which must be a pointer.
+// The body must not be larger than maxBytes (<= 0 => DefaultMaxBodySize), must not contain unknown fields or trailing data
+// and the Content-Type header, if present, must be application/json.
+// If decoding fails, an error response is written to w and a *RequestError is returned, so handlers can simply return.
+func DecodeJSONBody(w http.ResponseWriter, r *http.Request, dst any, maxBytes int64) error {
+	err := decodeJSONBody(w, r, dst, maxBytes)
+	if err != nil {
+		send(w, err.Status, err.Message)
+		return err
+	}
+	return nil
+}
+
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, dst any, maxBytes int64) *RequestError {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || mediaType != "application/json" {
+			return &RequestError{Status: http.StatusUnsupportedMediaType, Message: "content type must be application/json"}
+		}
+	}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		return &RequestError{Status: http.StatusBadRequest, Message: "empty request body"}
+	}
+	defer r.Body.Close()
+
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBodySize
+	}
+	body := http.MaxBytesReader(w, r.Body, maxBytes)
+
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(dst)
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.Is(err, io.EOF):
+			return &RequestError{Status: http.StatusBadRequest, Message: "empty request body"}
+		case err.Error() == "http: request body too large":
+			return &RequestError{Status: http.StatusRequestEntityTooLarge, Message: fmt.Sprintf("request body larger than %d bytes", maxBytes)}
+		case errors.As(err, &syntaxErr):
+			return &RequestError{Status: http.StatusBadRequest, Message: fmt.Sprintf("invalid request body: malformed JSON at position %d", syntaxErr.Offset)}
+		case errors.As(err, &typeErr):
+			return &RequestError{Status: http.StatusBadRequest, Message: fmt.Sprintf("invalid request body: wrong type of field '%s'", typeErr.Field)}
+		default:
+			return &RequestError{Status: http.StatusBadRequest, Message: "invalid request body: " + err.Error()}
+		}
+	}
+
+	if decoder.More() {
+		return &RequestError{Status: http.StatusBadRequest, Message: "invalid request body: unexpected data after JSON object"}
+	}
+	return nil
+}
@@ -0,0 +1,600 @@
+package cg
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// CommandRoomChat is sent by room members to send a chat message to all members of the room.
+	CommandRoomChat CommandName = "cg_room_chat"
+	// CommandRoomCreateGame is sent by the room host to create a game which all connected members join.
+	CommandRoomCreateGame CommandName = "cg_room_create_game"
+
+	EventRoomMembers     EventName = "cg_room_members"
+	EventRoomChat        EventName = "cg_room_chat"
+	EventRoomGameCreated EventName = "cg_room_game_created"
+)
+
+// The time after which a room with no connected members is deleted if RoomIdleTimeout is not set.
+const defaultRoomIdleTimeout = 10 * time.Minute
+
+var ErrMaxRoomsReached = errors.New("max room count reached")
+
+type RoomChatCommandData struct {
+	Message string `json:"message"`
+}
+
+type RoomCreateGameCommandData struct {
+	Public    bool            `json:"public"`
+	Protected bool            `json:"protected"`
+	Config    json.RawMessage `json:"config"`
+}
+
+type RoomMembersEventData struct {
+	// The ID of the member who may create games.
+	Host string `json:"host"`
+	// The usernames of all members by ID.
+	Members map[string]string `json:"members"`
+}
+
+type RoomChatEventData struct {
+	Member   string `json:"member"`
+	Username string `json:"username"`
+	Message  string `json:"message"`
+}
+
+// RoomGameCreatedEventData contains the credentials of the receiving member in the game created by the room host.
+type RoomGameCreatedEventData struct {
+	GameID       string `json:"game_id"`
+	JoinSecret   string `json:"join_secret,omitempty"`
+	PlayerID     string `json:"player_id"`
+	PlayerSecret string `json:"player_secret"`
+}
+
+// Room is a server-level group of users who chat and form a party before creating a game together.
+type Room struct {
+	ID string
+
+	server    *Server
+	createdAt time.Time
+
+	lock    sync.RWMutex
+	hostID  string
+	members map[string]*roomMember
+	// the last time the room was created or a member disconnected
+	idleSince time.Time
+}
+
+type roomMember struct {
+	id       string
+	username string
+	secret   string
+	address  string
+	lang     string
+	joinedAt time.Time
+
+	socketLock sync.Mutex
+	conn       *websocket.Conn
+}
+
+func (s *Server) roomRoutes(r chi.Router) {
+	r.Post("/", s.createRoomEndpoint)
+	r.Get("/{roomId}", s.roomEndpoint)
+	r.Post("/{roomId}/members", s.joinRoomEndpoint)
+	r.Get("/{roomId}/members/{memberId}/connect", s.roomConnectEndpoint)
+}
+
+func (s *Server) createRoom() (*Room, error) {
+	if s.closed() {
+		return nil, ErrServerClosed
+	}
+
+	now := s.config.Clock.Now()
+	room := &Room{
+		ID:        uuid.NewString(),
+		server:    s,
+		createdAt: now,
+		members:   make(map[string]*roomMember),
+		idleSince: now,
+	}
+
+	s.roomsLock.Lock()
+	if s.config.MaxRooms > 0 && len(s.rooms) >= s.config.MaxRooms {
+		s.roomsLock.Unlock()
+		return nil, ErrMaxRoomsReached
+	}
+	s.rooms[room.ID] = room
+	s.roomsLock.Unlock()
+
+	s.log.Info("Created room %s.", room.ID)
+	return room, nil
+}
+
+func (s *Server) getRoom(id string) (*Room, bool) {
+	s.roomsLock.RLock()
+	defer s.roomsLock.RUnlock()
+	room, ok := s.rooms[id]
+	return room, ok
+}
+
+func (s *Server) removeRoom(room *Room) {
+	s.roomsLock.Lock()
+	_, ok := s.rooms[room.ID]
+	delete(s.rooms, room.ID)
+	s.roomsLock.Unlock()
+	if ok {
+		s.log.Info("Removed room %s.", room.ID)
+	}
+}
+
+func (s *Server) watchRooms() {
+	ticker := s.config.Clock.NewTicker(s.config.RoomIdleTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C():
+			s.removeInactiveRooms()
+		case <-s.shutdown:
+			return
+		}
+	}
+}
+
+// removeInactiveRooms closes rooms which have had no connected members for RoomIdleTimeout.
+func (s *Server) removeInactiveRooms() {
+	s.roomsLock.RLock()
+	inactive := make([]*Room, 0)
+	for _, room := range s.rooms {
+		if room.connectedCount() == 0 && s.config.Clock.Now().Sub(room.lastActive()) >= s.config.RoomIdleTimeout {
+			inactive = append(inactive, room)
+		}
+	}
+	s.roomsLock.RUnlock()
+
+	for _, room := range inactive {
+		room.close()
+	}
+}
+
+// closeRooms closes all rooms and disconnects their members.
+func (s *Server) closeRooms() {
+	s.roomsLock.RLock()
+	rooms := make([]*Room, 0, len(s.rooms))
+	for _, room := range s.rooms {
+		rooms = append(rooms, room)
+	}
+	s.roomsLock.RUnlock()
+
+	for _, room := range rooms {
+		room.close()
+	}
+}
+
+// close removes all members, disconnects their sockets and removes the room from the server.
+func (r *Room) close() {
+	r.lock.Lock()
+	members := make([]*roomMember, 0, len(r.members))
+	for _, m := range r.members {
+		members = append(members, m)
+	}
+	r.members = make(map[string]*roomMember)
+	r.hostID = ""
+	r.lock.Unlock()
+
+	r.server.removeRoom(r)
+
+	for _, m := range members {
+		m.socketLock.Lock()
+		if m.conn != nil {
+			m.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "room closed"), time.Now().Add(5*time.Second))
+			m.conn.Close()
+		}
+		m.socketLock.Unlock()
+	}
+}
+
+func (r *Room) lastActive() time.Time {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.idleSince
+}
+
+func (r *Room) join(username, address, lang string) *roomMember {
+	member := &roomMember{
+		id:       uuid.NewString(),
+		username: username,
+		secret:   r.server.generateSecret(),
+		address:  address,
+		lang:     lang,
+		joinedAt: r.server.config.Clock.Now(),
+	}
+
+	r.lock.Lock()
+	r.members[member.id] = member
+	if r.hostID == "" {
+		r.hostID = member.id
+	}
+	r.lock.Unlock()
+
+	r.broadcastMembers()
+	return member
+}
+
+func (r *Room) leave(member *roomMember) {
+	r.lock.Lock()
+	delete(r.members, member.id)
+	if r.hostID == member.id {
+		r.hostID = ""
+		for _, m := range r.members {
+			if r.hostID == "" || m.joinedAt.Before(r.members[r.hostID].joinedAt) {
+				r.hostID = m.id
+			}
+		}
+	}
+	empty := len(r.members) == 0
+	r.lock.Unlock()
+
+	if empty {
+		r.server.removeRoom(r)
+		return
+	}
+	r.broadcastMembers()
+}
+
+func (r *Room) getMember(id string) (*roomMember, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	member, ok := r.members[id]
+	return member, ok
+}
+
+func (r *Room) connectedCount() int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	count := 0
+	for _, m := range r.members {
+		if m.connected() {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *Room) membersEventData() RoomMembersEventData {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	members := make(map[string]string, len(r.members))
+	for id, m := range r.members {
+		members[id] = m.username
+	}
+	return RoomMembersEventData{
+		Host:    r.hostID,
+		Members: members,
+	}
+}
+
+func (r *Room) broadcast(event EventName, data any) {
+	r.lock.RLock()
+	members := make([]*roomMember, 0, len(r.members))
+	for _, m := range r.members {
+		members = append(members, m)
+	}
+	r.lock.RUnlock()
+
+	for _, m := range members {
+		m.send(event, data)
+	}
+}
+
+func (r *Room) broadcastMembers() {
+	r.broadcast(EventRoomMembers, r.membersEventData())
+}
+
+// createGame creates a game and joins all members with a connected socket, which receive their credentials with the cg_room_game_created event.
+func (r *Room) createGame(member *roomMember, data RoomCreateGameCommandData) error {
+	r.lock.RLock()
+	isHost := r.hostID == member.id
+	members := make([]*roomMember, 0, len(r.members))
+	for _, m := range r.members {
+		members = append(members, m)
+	}
+	r.lock.RUnlock()
+
+	if !isHost {
+		return ErrNotHost
+	}
+
+	gameID, joinSecret, err := r.server.createGame(data.Public, data.Protected, r.server.config.AuthenticateRequest != nil, data.Config)
+	if err != nil {
+		return err
+	}
+
+	game, ok := r.server.getGame(gameID)
+	if !ok {
+		return ErrGameNotFound
+	}
+
+	for _, m := range members {
+		if !m.connected() {
+			continue
+		}
+
+		playerID, playerSecret, err := game.join(m.username, joinSecret, m.address, m.lang, "")
+		if err != nil {
+			m.sendCommandError(CommandRoomCreateGame, err, ErrorCommandRejected)
+			continue
+		}
+		m.send(EventRoomGameCreated, RoomGameCreatedEventData{
+			GameID:       gameID,
+			JoinSecret:   joinSecret,
+			PlayerID:     playerID,
+			PlayerSecret: playerSecret,
+		})
+	}
+
+	r.server.log.Info("Room %s created game %s.", r.ID, gameID)
+	return nil
+}
+
+func (r *Room) handleCommand(member *roomMember, cmd Command) error {
+	switch cmd.Name {
+	case CommandRoomChat:
+		var data RoomChatCommandData
+		err := cmd.UnmarshalData(&data)
+		if err != nil {
+			return err
+		}
+		r.broadcast(EventRoomChat, RoomChatEventData{
+			Member:   member.id,
+			Username: member.username,
+			Message:  data.Message,
+		})
+		return nil
+	case CommandRoomCreateGame:
+		var data RoomCreateGameCommandData
+		err := cmd.UnmarshalData(&data)
+		if err != nil {
+			return err
+		}
+		return r.createGame(member, data)
+	case CommandInviteAccept, CommandInviteDecline:
+		return r.server.answerInvite(member.id, cmd)
+	default:
+		return errors.New("unexpected command")
+	}
+}
+
+func (r *Room) handleConnection(member *roomMember, conn *websocket.Conn) {
+	member.socketLock.Lock()
+	if member.conn != nil {
+		member.conn.Close()
+	}
+	member.conn = conn
+	member.socketLock.Unlock()
+
+	member.send(EventRoomMembers, r.membersEventData())
+
+	var pongLock sync.Mutex
+	var lastPong time.Time
+	conn.SetReadDeadline(time.Now().Add(r.server.config.WebsocketTimeout))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(r.server.config.WebsocketTimeout))
+		pongLock.Lock()
+		lastPong = r.server.config.Clock.Now()
+		pongLock.Unlock()
+		return nil
+	})
+
+	done := make(chan struct{})
+	go r.ping(member, conn, done, func() time.Time {
+		pongLock.Lock()
+		defer pongLock.Unlock()
+		return lastPong
+	})
+
+	for {
+		msgType, msg, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		conn.SetReadDeadline(time.Now().Add(r.server.config.WebsocketTimeout))
+
+		var cmd Command
+		if msgType != websocket.TextMessage || json.Unmarshal(msg, &cmd) != nil || cmd.Name == "" {
+			member.sendCommandError("", ErrDecodeFailed, ErrorDecodeFailed)
+			continue
+		}
+
+		err = r.handleCommand(member, cmd)
+		if err != nil {
+			member.sendCommandError(cmd.Name, err, ErrorCommandRejected)
+		}
+	}
+	close(done)
+
+	member.socketLock.Lock()
+	replaced := member.conn != conn
+	if !replaced {
+		member.conn = nil
+	}
+	member.socketLock.Unlock()
+	conn.Close()
+
+	r.lock.Lock()
+	r.idleSince = r.server.config.Clock.Now()
+	r.lock.Unlock()
+
+	if !replaced {
+		r.leave(member)
+	}
+}
+
+// ping pings the connection of a room member every PingInterval until done is closed
+// and closes the connection if it fails to answer within PongTimeout.
+func (r *Room) ping(member *roomMember, conn *websocket.Conn, done <-chan struct{}, lastPong func() time.Time) {
+	clock := r.server.config.Clock
+	ticker := clock.NewTicker(r.server.config.PingInterval)
+	defer ticker.Stop()
+	var pongTimer Timer
+	defer func() {
+		if pongTimer != nil {
+			pongTimer.Stop()
+		}
+	}()
+	for {
+		select {
+		case <-ticker.C():
+			sentAt := clock.Now()
+			err := conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(r.server.config.PongTimeout))
+			if err != nil {
+				conn.Close()
+				return
+			}
+			if pongTimer != nil {
+				pongTimer.Stop()
+			}
+			pongTimer = clock.AfterFunc(r.server.config.PongTimeout, func() {
+				if lastPong().Before(sentAt) {
+					r.server.log.Trace("Member %s of room %s did not answer a ping within %s.", member.id, r.ID, r.server.config.PongTimeout)
+					conn.Close()
+				}
+			})
+		case <-done:
+			return
+		}
+	}
+}
+
+func (m *roomMember) connected() bool {
+	m.socketLock.Lock()
+	defer m.socketLock.Unlock()
+	return m.conn != nil
+}
+
+func (m *roomMember) send(event EventName, data any) error {
+	e := Event{
+		Name: event,
+	}
+	err := e.marshalData(data)
+	if err != nil {
+		return err
+	}
+
+	jsonData, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	m.socketLock.Lock()
+	defer m.socketLock.Unlock()
+	if m.conn == nil {
+		return nil
+	}
+	return m.conn.WriteMessage(websocket.TextMessage, jsonData)
+}
+
+func (s *Server) createRoomEndpoint(w http.ResponseWriter, r *http.Request) {
+	room, err := s.createRoom()
+	if errors.Is(err, ErrMaxRoomsReached) {
+		send(w, http.StatusForbidden, err.Error())
+		return
+	} else if errors.Is(err, ErrServerClosed) {
+		send(w, http.StatusServiceUnavailable, err.Error())
+		return
+	} else if err != nil {
+		send(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.joinRoom(w, r, room)
+}
+
+func (s *Server) joinRoomEndpoint(w http.ResponseWriter, r *http.Request) {
+	room, ok := s.getRoom(chi.URLParam(r, "roomId"))
+	if !ok {
+		send(w, http.StatusNotFound, "room not found")
+		return
+	}
+	s.joinRoom(w, r, room)
+}
+
+func (s *Server) joinRoom(w http.ResponseWriter, r *http.Request, room *Room) {
+	type request struct {
+		Username string `json:"username"`
+		Lang     string `json:"lang"`
+	}
+	var req request
+	err := DecodeJSONBody(w, r, &req, DefaultMaxBodySize)
+	if err != nil {
+		return
+	}
+	if req.Username == "" {
+		send(w, http.StatusBadRequest, "missing username")
+		return
+	}
+
+	member := room.join(req.Username, remoteHost(r), req.Lang)
+
+	type response struct {
+		RoomID       string `json:"room_id"`
+		MemberID     string `json:"member_id"`
+		MemberSecret string `json:"member_secret"`
+	}
+	sendJSON(w, http.StatusCreated, response{
+		RoomID:       room.ID,
+		MemberID:     member.id,
+		MemberSecret: member.secret,
+	})
+}
+
+func (s *Server) roomEndpoint(w http.ResponseWriter, r *http.Request) {
+	room, ok := s.getRoom(chi.URLParam(r, "roomId"))
+	if !ok {
+		send(w, http.StatusNotFound, "room not found")
+		return
+	}
+	sendJSON(w, http.StatusOK, room.membersEventData())
+}
+
+func (s *Server) roomConnectEndpoint(w http.ResponseWriter, r *http.Request) {
+	memberSecret := r.URL.Query().Get("member_secret")
+	if memberSecret == "" {
+		send(w, http.StatusBadRequest, "missing `member_secret` query parameter")
+		return
+	}
+
+	room, ok := s.getRoom(chi.URLParam(r, "roomId"))
+	if !ok {
+		send(w, http.StatusNotFound, "room not found")
+		return
+	}
+
+	member, ok := room.getMember(chi.URLParam(r, "memberId"))
+	if !ok {
+		send(w, http.StatusNotFound, "member not found")
+		return
+	}
+
+	if err := s.checkSecretFormat(memberSecret); err != nil {
+		send(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if member.secret != memberSecret {
+		send(w, http.StatusForbidden, "wrong member secret")
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	go room.handleConnection(member, conn)
+}
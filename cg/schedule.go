@@ -0,0 +1,33 @@
+package cg
+
+import "time"
+
+// Schedule runs fn after d on the goroutine which consumes the command queue with NextCommand or WaitForNextCommand.
+// This allows game loops to use timers without synchronizing with other goroutines.
+// The returned timer can be used to cancel fn before it has been queued.
+func (g *Game) Schedule(d time.Duration, fn func()) Timer {
+	return g.server.config.Clock.AfterFunc(d, func() {
+		g.enqueue(CommandWrapper{
+			scheduled: fn,
+		})
+	})
+}
+
+// ScheduleAt runs fn at t like Schedule.
+func (g *Game) ScheduleAt(t time.Time, fn func()) Timer {
+	return g.Schedule(t.Sub(g.server.config.Clock.Now()), fn)
+}
+
+// InjectCommand adds a command to the command queue as if it had been sent by origin.
+// origin may be nil for commands which do not originate from a player.
+// Injected commands are not checked by the CommandValidator. It returns ErrGameClosed if the game has been closed.
+func (g *Game) InjectCommand(origin *Player, cmd Command) error {
+	g.Log.TraceData(cmd, "Injecting '%s' command.", cmd.Name)
+	if !g.enqueue(CommandWrapper{
+		Origin: origin,
+		Cmd:    cmd,
+	}) {
+		return ErrGameClosed
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package cg
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"strings"
+)
+
+// ErrMalformedSecret is returned for secrets in the SecretToken format whose checksum does not match, e.g. because of a typo.
+var ErrMalformedSecret = errors.New("malformed secret")
+
+type SecretFormat string
+
+const (
+	// SecretRandom secrets consist of SecretLength random characters of SecretAlphabet.
+	SecretRandom SecretFormat = "random"
+	// SecretToken secrets consist of SecretLength random characters of SecretAlphabet followed by a checksum character,
+	// split into dash-separated groups of 4 characters, e.g. for secrets which are typed by hand.
+	SecretToken SecretFormat = "token"
+)
+
+const (
+	defaultSecretAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	defaultSecretLength   = 64
+
+	// The minimum recommended entropy of secrets in bits.
+	minSecretEntropy = 128
+)
+
+// secretEntropy returns the number of random bits of a secret with length characters of alphabet.
+func secretEntropy(length int, alphabet string) float64 {
+	return float64(length) * math.Log2(float64(len(alphabet)))
+}
+
+// validateSecretConfig applies the defaults of the secret config and resets invalid values.
+func (s *Server) validateSecretConfig() {
+	if s.config.SecretLength <= 0 {
+		s.config.SecretLength = defaultSecretLength
+	}
+
+	if s.config.SecretAlphabet == "" {
+		s.config.SecretAlphabet = defaultSecretAlphabet
+	} else if err := validateSecretAlphabet(s.config.SecretAlphabet); err != nil {
+		s.log.Error("Invalid secret alphabet: %s", err)
+		s.config.SecretAlphabet = defaultSecretAlphabet
+	}
+
+	switch s.config.SecretFormat {
+	case "":
+		s.config.SecretFormat = SecretRandom
+	case SecretRandom, SecretToken:
+	default:
+		s.log.Error("Invalid secret format: %s", s.config.SecretFormat)
+		s.config.SecretFormat = SecretRandom
+	}
+
+	if entropy := secretEntropy(s.config.SecretLength, s.config.SecretAlphabet); entropy < minSecretEntropy {
+		s.log.Warning("Secrets only contain %.0f random bits, at least %d are recommended.", entropy, minSecretEntropy)
+	}
+}
+
+func validateSecretAlphabet(alphabet string) error {
+	if len(alphabet) < 2 {
+		return fmt.Errorf("at least 2 characters required")
+	}
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		if c <= ' ' || c > '~' || c == '-' {
+			return fmt.Errorf("'%c' is not a printable ASCII character other than '-'", c)
+		}
+		if strings.IndexByte(alphabet[i+1:], c) >= 0 {
+			return fmt.Errorf("duplicate character '%c'", c)
+		}
+	}
+	return nil
+}
+
+// generateSecret generates a player, room member or join secret according to the secret config.
+func (s *Server) generateSecret() string {
+	alphabet := s.config.SecretAlphabet
+	ret := make([]byte, s.config.SecretLength)
+	for i := range ret {
+		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			panic(err)
+		}
+		ret[i] = alphabet[num.Int64()]
+	}
+
+	if s.config.SecretFormat == SecretToken {
+		return formatSecretToken(append(ret, secretChecksum(ret, alphabet)))
+	}
+	return string(ret)
+}
+
+// secretChecksum returns the Luhn mod N check character of secret, where N is the length of alphabet.
+// It detects every single typo and every swap of neighbours, except of the first and the last character
+// of alphabet if its length is even.
+func secretChecksum(secret []byte, alphabet string) byte {
+	n := len(alphabet)
+	sum := 0
+	double := true
+	for i := len(secret) - 1; i >= 0; i-- {
+		value := strings.IndexByte(alphabet, secret[i])
+		if double {
+			value = luhnDouble(value, n)
+		}
+		sum += value
+		double = !double
+	}
+	return alphabet[(n-sum%n)%n]
+}
+
+// luhnDouble doubles value like the Luhn algorithm by adding the digits of 2*value in base n.
+// For odd n, 2*value mod n is used instead, which detects all swaps of neighbours.
+// Both are permutations of 0..n-1, so every single typo is detected.
+func luhnDouble(value, n int) int {
+	if n%2 == 1 {
+		return 2 * value % n
+	}
+	value *= 2
+	if value >= n {
+		value = value - n + 1
+	}
+	return value
+}
+
+// checkSecretFormat returns ErrMalformedSecret if secrets are generated in the SecretToken format
+// and secret is not a token with a valid checksum. Secrets of the SecretRandom format are not checked.
+func (s *Server) checkSecretFormat(secret string) error {
+	if s.config.SecretFormat != SecretToken {
+		return nil
+	}
+	alphabet := s.config.SecretAlphabet
+	token := []byte(strings.ReplaceAll(secret, "-", ""))
+	if len(token) != s.config.SecretLength+1 {
+		return ErrMalformedSecret
+	}
+	for _, c := range token {
+		if strings.IndexByte(alphabet, c) < 0 {
+			return ErrMalformedSecret
+		}
+	}
+	if secretChecksum(token[:len(token)-1], alphabet) != token[len(token)-1] {
+		return ErrMalformedSecret
+	}
+	return nil
+}
+
+// checkPlayerSecret responds with 400 Bad Request if the secret is malformed and with 403 Forbidden
+// if it is not the secret of the player.
+func (s *Server) checkPlayerSecret(w http.ResponseWriter, player *Player, secret string) bool {
+	if err := s.checkSecretFormat(secret); err != nil {
+		send(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	if player.Secret != secret {
+		send(w, http.StatusForbidden, "wrong player secret")
+		return false
+	}
+	return true
+}
+
+func formatSecretToken(token []byte) string {
+	var b strings.Builder
+	for i, c := range token {
+		if i > 0 && i%4 == 0 {
+			b.WriteByte('-')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -1,12 +1,14 @@
 package cg
 
 import (
-	"crypto/rand"
+	"container/list"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
-	"math/big"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
@@ -18,19 +20,61 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
-	"github.com/rs/cors"
 )
 
 type Server struct {
-	gamesLock sync.RWMutex
-	games     map[string]*Game
+	gamesLock    sync.RWMutex
+	games        map[string]*Game
+	shuttingDown bool
+	// closed by Shutdown
+	shutdown chan struct{}
+	// the goroutines running runGameFunc
+	gameFuncs sync.WaitGroup
+
+	listenersLock sync.Mutex
+	httpServer    *http.Server
+	tcpListener   net.Listener
+	pprofServer   *http.Server
+
+	zombieLock       sync.Mutex
+	zombieGames      int
+	reapedGamesTotal int
+
+	roomsLock sync.RWMutex
+	rooms     map[string]*Room
+
+	// the sockets connected to /api/spectate
+	multiSpectatorsLock sync.Mutex
+	multiSpectators     map[string]*GameSocket
+
+	invitesLock sync.Mutex
+	invites     map[string]*Invite
+
+	maintenanceLock sync.RWMutex
+	maintenance     *maintenanceInfo
+
+	presetsLock sync.RWMutex
+	presets     map[string]Preset
+
+	autostartOnce sync.Once
+
+	tombstonesLock sync.Mutex
+	tombstones     map[string]*list.Element
+	tombstoneOrder *list.List
+
+	apiRoutesLock sync.Mutex
+	customRoutes  []apiRoute
+
+	// the path the API is served at as seen by clients, e.g. /api/{gameName} for a game type of a MultiServer
+	apiPath string
 
 	upgrader websocket.Upgrader
 	config   ServerConfig
 
 	log *Logger
 
-	killTicker *time.Ticker
+	startedAt  time.Time
+	killTicker Ticker
 
 	runGameFunc func(game *Game, config json.RawMessage)
 }
@@ -38,20 +82,71 @@ type Server struct {
 type ServerConfig struct {
 	// The port to listen on for new websocket connections. (default: 80)
 	Port int
+	// The address to listen on instead of Port on all interfaces, e.g. "127.0.0.1:8080" or "unix:///run/game.sock".
+	ListenAddr string
+	// The origins, headers and credentials allowed in cross-origin requests and websocket connections. (default: all origins)
+	CORS CORSConfig
+	// The origin check, buffer sizes, compression and handshake timeout of websocket connections.
+	Upgrader UpgraderConfig
+	// Serve the profiles of net/http/pprof, e.g. to capture CPU and heap profiles in production.
+	EnablePprof bool
+	// The path the profiles are served at. (default: /debug/pprof)
+	PprofPath string
+	// The address of a separate webserver for the profiles started by Run, e.g. "localhost:6060".
+	// (empty => served by the main webserver and protected by AdminToken)
+	PprofAddr string
+	// The destination of the log messages of the server, e.g. an adapter for another logging library. (default: ConsoleSink)
+	LogSink LogSink
+	// The directory in which the messages of the game and player loggers are appended to a file per game
+	// as JSON lines, e.g. to investigate sessions after their debug sockets are gone. (empty => no log files)
+	LogDir string
+	// The size in bytes at which a log file is rotated. (default: 10 MiB)
+	LogMaxSize int64
+	// The number of rotated log files kept per game. (default: 3, negative => no backups)
+	LogMaxBackups int
+	// Tracer creates spans for HTTP requests and for commands from their arrival until the game loop takes them. (nil => no tracing)
+	Tracer Tracer
+	// The port of the newline-delimited JSON TCP listener started by Run on the host of ListenAddr. (0 => disabled)
+	TCPPort int
+	// The certificate and matching private key files used by RunTLS.
+	TLSCertFile string
+	TLSKeyFile  string
+	// The TLS configuration used by RunTLS, e.g. from autocert.Manager.TLSConfig() to obtain certificates automatically.
+	// The certificate files are loaded into it if they are set.
+	TLSConfig *tls.Config
 	// The path to the CGE file for the game.
 	EventsPath string
+	// The prefix all events and commands in the CGE file must start with, e.g. "chess_". (empty => not enforced)
+	// Names starting with cg_ are always rejected because they are reserved for standard events and commands.
+	EventPrefix string
 	// The path to the logo file for the game.
 	LogoPath string
 	// All files in this direcory will be served as part of the frontend.
 	Frontend fs.FS
+	// Process HTML files of the frontend as html/template templates with information about the server,
+	// e.g. {{.DisplayName}}, {{.Version}} or {{.APIURL}}.
+	FrontendTemplates bool
+	// How requests for missing frontend files are handled. (default: FrontendSPA)
+	FrontendMode FrontendMode
+	// The path of a page in Frontend which is served with status 404 for missing files.
+	FrontendNotFoundPage string
+	// Require the secret of a player of the game or the join secret of a protected game in the player_secret or join_secret
+	// query parameter to list the players with /api/games/{gameId}/players or to get a player with /api/games/{gameId}/players/{playerId}.
+	ProtectPlayerList bool
 	// The maximum number of allowed sockets per player (0 => unlimited).
 	MaxSocketsPerPlayer int
+	// What happens when a socket connects for a player who already has MaxSocketsPerPlayer sockets. (default: RejectNewSocket)
+	SingleSocketPolicy SocketPolicy
 	// The maximum number of allowed players per game (0 => unlimited).
 	MaxPlayersPerGame int
 	// The maximum number of allowed spectators per game (0 => unlimited).
 	MaxSpectatorsPerGame int
 	// The maximum number of games (0 => unlimited).
 	MaxGames int
+	// The maximum number of rooms (0 => unlimited).
+	MaxRooms int
+	// The time after which a room with no connected members will be deleted. (default: 10 minutes)
+	RoomIdleTimeout time.Duration
 	// The time after which game with no connected sockets will be deleted. (0 => never)
 	DeleteInactiveGameDelay time.Duration
 	// The time after which a player without sockets will be kicked. (0 => never)
@@ -66,8 +161,82 @@ type ServerConfig struct {
 	Description string
 	// The URL to the code repository of the game.
 	RepositoryURL string
+	// The region the server is located in, e.g. "eu-west", for clients choosing the closest server.
+	Region string
+	// The persistent public games created at startup and recreated when they are closed, e.g. drop-in lobbies.
+	AutostartGames []GamePreset
+	// The palette of distinct colors assigned to the players of a game when they join. (default: DefaultPlayerColors)
+	PlayerColors []string
+	// Localized texts of the game by language tag, e.g. "de". Players choose a locale with the lang field when joining.
+	Locales map[string]Locale
+	// The number of recent events sent to each player which are kept for /api/games/{gameId}/players/{playerId}/debug/journal. (0 => disabled)
+	EventJournalSize int
+	// Only every n-th trace message with the same text is logged by the server, game and player loggers. (0 => log all)
+	TraceSampling int
+	// Send the events a player missed while it had no sockets in a single cg_missed_events event.
+	BatchMissedEvents bool
+	// The time after the last socket of a player disconnected during which the player is still considered online.
+	// KickInactivePlayerDelay starts after this period. (0 => no grace period)
+	ReconnectGracePeriod time.Duration
+	// The time after which a game whose game loop has not taken a queued command is considered a zombie. (0 => disabled)
+	ZombieTimeout time.Duration
+	// Close zombie games instead of only logging them.
+	CloseZombieGames bool
 	// The time after which an inactive websocket connection will be closed. (default: 15 minutes)
 	WebsocketTimeout time.Duration
+	// The number of bytes per second which may be sent to a player before low-priority events are dropped. (0 => unlimited)
+	MaxBandwidthPerPlayer int
+	// The number of bytes per second which may be sent to all players and spectators of a game before low-priority events are dropped. (0 => unlimited)
+	MaxBandwidthPerGame int
+	// The number of consecutive failed writes after which a socket is disconnected. (default: 3)
+	MaxSocketWriteFailures int
+	// The number of events queued for a spectator socket before it is disconnected for not keeping up,
+	// so a slow spectator doesn't delay the broadcasts of its game. (0 => events are sent directly)
+	SpectatorQueueSize int
+	// The interval in which websocket connections are pinged. (default: 90% of WebsocketTimeout)
+	PingInterval time.Duration
+	// The time after which a socket which did not answer a ping is disconnected. (default: 30 seconds)
+	PongTimeout time.Duration
+	// The interval in which Game.OnConnectionQuality is called. (0 => never)
+	ConnectionQualityInterval time.Duration
+	// The number of rejected commands after which a player will be kicked from the game. (0 => never)
+	KickAfterStrikes int
+	// The number of rejected commands after which a player will be banned from the game. (0 => never)
+	// Banned players cannot rejoin the game from the same remote address.
+	BanAfterStrikes int
+	// Intercepts all messages sent to game sockets. (default: direct delivery)
+	Transport Transport
+	// Called with every message received from a game socket before it is decoded, e.g. to decrypt it, translate
+	// a custom encoding or enforce size limits. The returned message is decoded instead, returning an error rejects it.
+	// Binary messages are only accepted if OnRawMessage is set.
+	OnRawMessage func(socket *GameSocket, message []byte) ([]byte, error)
+	// The clock used for inactivity and timeout logic. (default: system clock)
+	Clock Clock
+	// Webhooks which are notified about server and game events.
+	Notifications []NotificationConfig
+	// The number of random characters of player, room member and join secrets.
+	// The default of 64 characters of the default alphabet contains about 381 random bits. (default: 64)
+	SecretLength int
+	// The characters secrets are made of. Shorter alphabets, e.g. for QR codes, require a longer SecretLength
+	// for the same entropy. A warning is logged if secrets contain fewer than 128 random bits. (default: 0-9, A-Z, a-z)
+	SecretAlphabet string
+	// The format of secrets. (default: SecretRandom)
+	SecretFormat SecretFormat
+	// The time to wait for a new game to poll the command queue for the first time before it is considered running.
+	// It is measured with Clock. Creating a game fails if the game function panics or returns within this time. (default: 1 second)
+	GameInitTimeout time.Duration
+	// The time Run and RunContext wait for the game functions to return when their context is cancelled
+	// or the webserver fails. (default: 30 seconds)
+	ShutdownTimeout time.Duration
+	// Reports whether a request which creates a game is authenticated. Games created by unauthenticated requests
+	// and by rooms are trial games with the limits of TrialGames. (nil => no trial games)
+	AuthenticateRequest func(r *http.Request) bool
+	// The stricter limits of trial games, e.g. for public demo servers.
+	TrialGames TrialConfig
+	// The token required to access the admin API (empty => admin API disabled).
+	AdminToken string
+	// Serve the embedded lobby at /lobby and admin interface at /admin (/{gameName}/lobby and /{gameName}/admin for a MultiServer).
+	EnableWebUI bool
 }
 
 type EventSender interface {
@@ -78,41 +247,105 @@ func NewServer(name string, config ServerConfig) *Server {
 	config.Name = name
 
 	server := &Server{
+		apiPath: "/api",
+
 		games: make(map[string]*Game),
+		rooms: make(map[string]*Room),
+
+		multiSpectators: make(map[string]*GameSocket),
 
-		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool { return true },
-		},
+		invites: make(map[string]*Invite),
+		presets: make(map[string]Preset),
+
+		shutdown: make(chan struct{}),
+
+		tombstones:     make(map[string]*list.Element),
+		tombstoneOrder: list.New(),
 
 		config: config,
 		log:    NewLogger(true),
 	}
+	server.upgrader = server.newUpgrader()
+	server.log.SetTraceSampling(config.TraceSampling)
 
 	if server.config.Port == 0 {
 		server.config.Port = 80
 	}
 
+	if server.config.FrontendMode == "" {
+		server.config.FrontendMode = FrontendSPA
+	}
+
 	if server.config.EventsPath == "" {
 		log.Warn("No CGE file location specified!")
 	}
 
+	if server.config.Clock == nil {
+		server.config.Clock = realClock{}
+	}
+
 	if server.config.WebsocketTimeout == 0 {
 		server.config.WebsocketTimeout = 15 * time.Minute
 	}
 
+	if server.config.MaxSocketWriteFailures == 0 {
+		server.config.MaxSocketWriteFailures = 3
+	}
+
+	if server.config.PingInterval == 0 {
+		server.config.PingInterval = (server.config.WebsocketTimeout * 9) / 10
+	}
+
+	server.validateSecretConfig()
+
+	if len(server.config.PlayerColors) == 0 {
+		server.config.PlayerColors = DefaultPlayerColors
+	}
+
+	if server.config.PprofPath == "" {
+		server.config.PprofPath = defaultPprofPath
+	}
+	server.config.PprofPath = "/" + strings.Trim(server.config.PprofPath, "/")
+
+	if server.config.RoomIdleTimeout == 0 {
+		server.config.RoomIdleTimeout = defaultRoomIdleTimeout
+	}
+	go server.watchRooms()
+
+	if server.config.GameInitTimeout == 0 {
+		server.config.GameInitTimeout = defaultGameInitTimeout
+	}
+
+	if server.config.ShutdownTimeout == 0 {
+		server.config.ShutdownTimeout = defaultShutdownTimeout
+	}
+
+	if server.config.PongTimeout == 0 {
+		server.config.PongTimeout = 30 * time.Second
+	}
+
 	if server.config.KickInactivePlayerDelay > 0 || server.config.DeleteInactiveGameDelay > 0 {
 		duration := server.config.KickInactivePlayerDelay
 		if server.config.DeleteInactiveGameDelay > 0 && (duration == 0 || duration > server.config.DeleteInactiveGameDelay) {
 			duration = server.config.DeleteInactiveGameDelay
 		}
-		server.killTicker = time.NewTicker(duration)
+		server.killTicker = server.config.Clock.NewTicker(duration)
 		go func() {
-			for range server.killTicker.C {
-				server.removeInactiveGamesPlayers()
+			for {
+				select {
+				case <-server.killTicker.C():
+					server.removeInactiveGamesPlayers()
+				case <-server.shutdown:
+					return
+				}
 			}
 		}()
 	}
 
+	if server.config.ZombieTimeout > 0 {
+		go server.watchZombies()
+	}
+
 	if server.config.Version == "" {
 		log.Warn("No game version specified.")
 	} else {
@@ -123,6 +356,20 @@ func NewServer(name string, config ServerConfig) *Server {
 		}
 	}
 
+	if server.config.LogMaxSize == 0 {
+		server.config.LogMaxSize = defaultLogMaxSize
+	}
+
+	if server.config.LogMaxBackups == 0 {
+		server.config.LogMaxBackups = defaultLogMaxBackups
+	}
+
+	if server.config.LogSink != nil {
+		server.log.SetSink(server.config.LogSink)
+	}
+
+	server.startedAt = server.config.Clock.Now()
+
 	return server
 }
 
@@ -157,51 +404,206 @@ func parseVersion(version string) (int, int, int, error) {
 }
 
 // Run starts the webserver and listens for new connections.
+// It exits the process if a listener fails, see RunContext.
 func (s *Server) Run(runGameFunc func(game *Game, config json.RawMessage)) {
+	err := s.RunContext(context.Background(), runGameFunc)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// RunContext starts the webserver like Run but returns the error of a failing listener instead of exiting the process.
+// The server is shut down with Shutdown when ctx is done or a listener fails.
+// It returns nil after the server has been shut down.
+func (s *Server) RunContext(ctx context.Context, runGameFunc func(game *Game, config json.RawMessage)) error {
+	return s.run(ctx, runGameFunc, false, nil)
+}
+
+// run serves the API on l or on a new listener for ListenAddr if l is nil.
+func (s *Server) run(ctx context.Context, runGameFunc func(game *Game, config json.RawMessage), useTLS bool, l net.Listener) error {
+	err := s.ValidateEvents()
+	if err != nil {
+		return err
+	}
+
+	handler := s.Handler(runGameFunc)
+
+	var tlsConfig *tls.Config
+	if useTLS {
+		tlsConfig, err = s.tlsConfig()
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.closed() {
+		return nil
+	}
+
+	if l == nil {
+		l, err = s.listen()
+		if err != nil {
+			return err
+		}
+	}
+
+	// one slot for each of the HTTP and the TCP listener
+	errs := make(chan error, 2)
+
+	err = s.startTCP(tlsConfig, errs)
+	if err != nil {
+		l.Close()
+		return err
+	}
+
+	httpServer := &http.Server{
+		Handler:   handler,
+		TLSConfig: tlsConfig,
+	}
+	s.listenersLock.Lock()
+	s.httpServer = httpServer
+	s.listenersLock.Unlock()
+	// Shutdown may have been called before the server was registered
+	if s.closed() {
+		l.Close()
+		return nil
+	}
+
+	if s.config.EnablePprof && s.config.PprofAddr != "" {
+		s.startPprofServer()
+	}
+
+	s.StartAutostartGames()
+
+	log.Infof("Listening on %s...", l.Addr())
+	s.notify(NotificationServerStarted, "", "The server is now online.")
+	go func() {
+		var err error
+		if tlsConfig != nil {
+			err = httpServer.ServeTLS(l, "", "")
+		} else {
+			err = httpServer.Serve(l)
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			errs <- err
+		}
+	}()
+
+	select {
+	case err := <-errs:
+		s.shutdownWithTimeout()
+		return err
+	case <-ctx.Done():
+		err := s.shutdownWithTimeout()
+		if errors.Is(err, ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-s.shutdown:
+		return nil
+	}
+}
+
+// startTCP listens for TCP connections on TCPPort if it is set and sends the error of the listener to errs
+// unless the server has been shut down.
+func (s *Server) startTCP(tlsConfig *tls.Config, errs chan<- error) error {
+	if s.config.TCPPort <= 0 {
+		return nil
+	}
+	tcpListener, err := s.listenTCP()
+	if err != nil {
+		return err
+	}
+	if tlsConfig != nil {
+		tcpListener = tls.NewListener(tcpListener, tlsConfig)
+	}
+	s.listenersLock.Lock()
+	s.tcpListener = tcpListener
+	s.listenersLock.Unlock()
+	log.Infof("Listening for TCP connections on %s...", tcpListener.Addr())
+	go func() {
+		err := s.ServeTCP(tcpListener)
+		if !s.closed() {
+			errs <- err
+		}
+	}()
+	return nil
+}
+
+// Handler returns the HTTP handler serving the API and the frontend without starting a webserver,
+// e.g. to mount the server with http.StripPrefix under a custom mux with custom listeners, timeouts and middleware.
+// runGameFunc is called in a new goroutine for every created game. Call StartAutostartGames to create the AutostartGames.
+// Use Shutdown to close the games when the embedding server is stopped.
+func (s *Server) Handler(runGameFunc func(game *Game, config json.RawMessage)) http.Handler {
 	s.runGameFunc = runGameFunc
 
 	router := chi.NewMux()
 	router.Use(middleware.Recoverer)
+	router.Use(s.traceRequests)
 	router.Route("/api", s.apiRoutes)
+	if s.config.EnablePprof && s.config.PprofAddr == "" {
+		router.Mount(s.config.PprofPath, s.requireAdmin(s.pprofHandler()))
+	}
+	router.Group(s.uiRoutes)
 	router.Route("/", s.frontendRoutes)
 
-	handler := cors.New(cors.Options{
-		AllowedOrigins: []string{"*"},
-		AllowedHeaders: []string{"*"},
-		AllowedMethods: []string{"GET", "HEAD", "POST", "PUT", "DELETE", "CONNECT", "OPTIONS", "TRACE", "PATCH"},
-	}).Handler(router)
+	return s.corsHandler(router)
+}
 
-	log.Infof("Listening on port %d...", s.config.Port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", s.config.Port), handler))
+func (s *Server) createGame(public, protected, trial bool, config json.RawMessage) (string, string, error) {
+	return s.createGameWith(public, protected, trial, config, nil)
 }
 
-func (s *Server) createGame(public, protected bool, config json.RawMessage) (string, string, error) {
-	s.gamesLock.Lock()
-	defer s.gamesLock.Unlock()
+// createGameWith creates a game like createGame and calls init with it before runGameFunc.
+func (s *Server) createGameWith(public, protected, trial bool, config json.RawMessage, init func(game *Game)) (string, string, error) {
+	if s.runGameFunc == nil {
+		return "", "", ErrNoGameFunc
+	}
 
+	s.gamesLock.Lock()
+	if s.shuttingDown {
+		s.gamesLock.Unlock()
+		return "", "", ErrServerClosed
+	}
 	if s.config.MaxGames > 0 && len(s.games) >= s.config.MaxGames {
-		return "", "", errors.New("max game count reached")
+		s.gamesLock.Unlock()
+		return "", "", ErrMaxGamesReached
+	}
+	if trial && s.config.TrialGames.MaxGames > 0 && s.trialGameCount() >= s.config.TrialGames.MaxGames {
+		s.gamesLock.Unlock()
+		return "", "", ErrMaxTrialGamesReached
 	}
 
 	id := uuid.NewString()
 
 	game := newGame(s, id, public)
+	game.trial = trial
 
 	if protected {
-		game.joinSecret = generateSecret()
+		game.joinSecret = s.generateSecret()
+	}
+
+	if init != nil {
+		init(game)
 	}
 
 	s.games[id] = game
+	s.gameFuncs.Add(1)
+	s.gamesLock.Unlock()
 
-	go func() {
-		s.runGameFunc(game, config)
-		game.Close()
-	}()
+	err := s.startGame(game, config)
+	if err != nil {
+		s.log.Error("Failed to create game %s: %s", id, err)
+		return "", "", err
+	}
+	game.expireTrial()
 
 	if public {
 		s.log.Info("Created public game %s.", id)
+		s.notify(NotificationGameCreated, id, "A new public game has been created: %s", id)
 	} else {
 		s.log.Info("Created private game %s-****-****-****-************.", id[:8])
+		s.notify(NotificationGameCreated, "", "A new private game has been created.")
 	}
 
 	return id, game.joinSecret, nil
@@ -211,45 +613,105 @@ func (s *Server) removeGame(game *Game) {
 	s.gamesLock.Lock()
 	delete(s.games, game.ID)
 	s.gamesLock.Unlock()
+	s.addTombstone(game)
+	s.restartAutostartGame(game)
 }
 
 func (s *Server) removeInactiveGamesPlayers() {
 	for _, g := range s.games {
 		g.kickInactivePlayers()
 
-		if s.config.DeleteInactiveGameDelay > 0 {
+		// autostart games are meant to stay available while empty
+		if s.config.DeleteInactiveGameDelay > 0 && g.autostart.Name == "" {
 			g.playersLock.RLock()
 			playerCount := len(g.players)
 			g.playersLock.RUnlock()
 
 			if playerCount == 0 {
 				if g.markedAsEmpty.Equal(time.Time{}) {
-					g.markedAsEmpty = time.Now()
-				} else if time.Now().After(g.markedAsEmpty.Add(s.config.DeleteInactiveGameDelay)) {
-					g.Close()
+					g.markedAsEmpty = s.config.Clock.Now()
+				} else if s.config.Clock.Now().After(g.markedAsEmpty.Add(s.config.DeleteInactiveGameDelay)) {
+					g.CloseWithReason("inactive")
 				}
 			}
 		}
 	}
 }
 
-func (s *Server) getGame(gameID string) (*Game, bool) {
+// ForgetPlayer removes the player from its game and purges all data the server keeps about it,
+// also if the player has already left its game. The lines containing the player ID are removed from the log files in LogDir
+// and the entries referring to the player ID are removed from the results of recently closed games.
+// It returns false if the server has no data about a player with the given ID.
+func (s *Server) ForgetPlayer(playerID string) bool {
+	found := false
+	if player, ok := s.findPlayer(playerID); ok {
+		player.game.forget(player)
+		found = true
+	}
+
 	s.gamesLock.RLock()
-	game, ok := s.games[gameID]
+	games := make([]*Game, 0, len(s.games))
+	for _, g := range s.games {
+		games = append(games, g)
+	}
 	s.gamesLock.RUnlock()
-	return game, ok
+	for _, g := range games {
+		if g.purgePlayer(playerID) {
+			found = true
+		}
+	}
+
+	s.invitesLock.Lock()
+	for id, i := range s.invites {
+		if i.Recipient == playerID {
+			delete(s.invites, id)
+			found = true
+		}
+	}
+	s.invitesLock.Unlock()
+
+	if s.purgeTombstoneResults(playerID) {
+		found = true
+	}
+
+	if s.redactLogFiles(playerID) {
+		found = true
+	}
+
+	if found {
+		s.log.Info("Purged all data of player %s.", playerID)
+	}
+	return found
 }
 
-func generateSecret() string {
-	const letters = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
-	ret := make([]byte, 64)
-	for i := 0; i < 64; i++ {
-		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(letters))))
-		if err != nil {
-			panic(err)
+// findOpenGame returns the public, unprotected game with the most players which is not full.
+func (s *Server) findOpenGame() (*Game, bool) {
+	s.gamesLock.RLock()
+	defer s.gamesLock.RUnlock()
+
+	var best *Game
+	bestCount := -1
+	for _, g := range s.games {
+		if !g.public || g.joinSecret != "" || !g.Running() {
+			continue
+		}
+		g.playersLock.RLock()
+		count := len(g.players)
+		g.playersLock.RUnlock()
+		if s.config.MaxPlayersPerGame > 0 && count >= s.config.MaxPlayersPerGame {
+			continue
+		}
+		if count > bestCount {
+			best = g
+			bestCount = count
 		}
-		ret[i] = letters[num.Int64()]
 	}
+	return best, best != nil
+}
 
-	return string(ret)
+func (s *Server) getGame(gameID string) (*Game, bool) {
+	s.gamesLock.RLock()
+	game, ok := s.games[gameID]
+	s.gamesLock.RUnlock()
+	return game, ok
 }
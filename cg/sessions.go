@@ -0,0 +1,75 @@
+package cg
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// The minimum length of client secrets. Client secrets should be random, e.g. generated with crypto.getRandomValues.
+const minClientSecretLength = 32
+
+type sessionInfo struct {
+	GameID       string `json:"game_id"`
+	PlayerID     string `json:"player_id"`
+	PlayerSecret string `json:"player_secret"`
+	Username     string `json:"username"`
+}
+
+// sessionsEndpoint returns the players of all games which were joined with the client secret in the secret query parameter,
+// so that clients which lost their stored credentials can reconnect.
+func (s *Server) sessionsEndpoint(w http.ResponseWriter, r *http.Request) {
+	secret := r.URL.Query().Get("secret")
+	if secret == "" {
+		send(w, http.StatusBadRequest, "missing secret")
+		return
+	}
+	hash, err := hashClientSecret(secret)
+	if err != nil {
+		send(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sessions := make([]sessionInfo, 0)
+	s.gamesLock.RLock()
+	for _, g := range s.games {
+		g.playersLock.RLock()
+		for _, p := range g.players {
+			if p.clientSecret != "" && subtle.ConstantTimeCompare([]byte(p.clientSecret), []byte(hash)) == 1 {
+				sessions = append(sessions, sessionInfo{
+					GameID:       g.ID,
+					PlayerID:     p.ID,
+					PlayerSecret: p.Secret,
+					Username:     p.username(),
+				})
+			}
+		}
+		g.playersLock.RUnlock()
+	}
+	s.gamesLock.RUnlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].GameID != sessions[j].GameID {
+			return sessions[i].GameID < sessions[j].GameID
+		}
+		return sessions[i].PlayerID < sessions[j].PlayerID
+	})
+
+	sendJSON(w, http.StatusOK, sessions)
+}
+
+// hashClientSecret returns the hash of the client secret which is stored instead of the secret itself.
+// It returns an empty string for an empty secret and an error if the secret is too short.
+func hashClientSecret(secret string) (string, error) {
+	if secret == "" {
+		return "", nil
+	}
+	if len(secret) < minClientSecretLength {
+		return "", fmt.Errorf("client secret must be at least %d characters long", minClientSecretLength)
+	}
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:]), nil
+}
@@ -0,0 +1,107 @@
+package cg
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+const (
+	// CommandUpdateSettings is sent by the host to update the settings of the game with a JSON merge patch.
+	CommandUpdateSettings CommandName = "cg_update_settings"
+
+	EventSettingsChanged EventName = "cg_settings_changed"
+)
+
+var ErrNotHost = NewError(ErrorNotHost, "only the host can do this")
+
+type SettingsChangedEventData struct {
+	Config any `json:"config"`
+}
+
+// Host returns the host of the game, which is the player who has been in the game the longest.
+func (g *Game) Host() *Player {
+	g.configLock.RLock()
+	hostID := g.hostID
+	g.configLock.RUnlock()
+	player, _ := g.GetPlayer(hostID)
+	return player
+}
+
+// SetHost makes player the host of the game.
+func (g *Game) SetHost(player *Player) {
+	g.configLock.Lock()
+	g.hostID = player.ID
+	g.configLock.Unlock()
+}
+
+// UpdateSettings merges patch (RFC 7386 JSON merge patch) into the game config if player is the host.
+// The new config keeps the type of the value passed to SetConfig and is broadcast with the cg_settings_changed event.
+func (g *Game) UpdateSettings(player *Player, patch json.RawMessage) error {
+	g.configLock.Lock()
+	if player == nil || player.ID != g.hostID {
+		g.configLock.Unlock()
+		return ErrNotHost
+	}
+
+	current, err := json.Marshal(g.config)
+	if err != nil {
+		g.configLock.Unlock()
+		return err
+	}
+	var currentValue, patchValue any
+	if err = json.Unmarshal(current, &currentValue); err != nil {
+		g.configLock.Unlock()
+		return err
+	}
+	if err = json.Unmarshal(patch, &patchValue); err != nil {
+		g.configLock.Unlock()
+		return err
+	}
+	merged, err := json.Marshal(mergePatch(currentValue, patchValue))
+	if err != nil {
+		g.configLock.Unlock()
+		return err
+	}
+
+	var config any
+	if g.config != nil {
+		typ := reflect.TypeOf(g.config)
+		ptr := reflect.New(typ)
+		if err = json.Unmarshal(merged, ptr.Interface()); err != nil {
+			g.configLock.Unlock()
+			return err
+		}
+		config = ptr.Elem().Interface()
+	} else {
+		config = json.RawMessage(merged)
+	}
+	g.config = config
+	g.configLock.Unlock()
+
+	g.Log.InfoData(patch, "Host '%s' (%s) updated the settings.", player.username(), player.ID)
+
+	if g.OnSettingsChanged != nil {
+		g.OnSettingsChanged(config)
+	}
+
+	return g.SendSettingsChanged(config)
+}
+
+func mergePatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = make(map[string]any)
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+		} else {
+			targetObj[key] = mergePatch(targetObj[key], value)
+		}
+	}
+	return targetObj
+}
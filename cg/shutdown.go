@@ -0,0 +1,97 @@
+package cg
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrServerClosed is returned when creating a game after Shutdown has been called.
+var ErrServerClosed = errors.New("server closed")
+
+// The time Run and RunContext wait for the games to close if ShutdownTimeout is not set.
+const defaultShutdownTimeout = 30 * time.Second
+
+// Shutdown stops accepting new HTTP, websocket and TCP connections, closes all games, rooms and their sockets,
+// disconnects all debug and /api/spectate sockets, stops the background tasks of the server
+// and waits until all game functions have returned or ctx expires.
+// Run and RunContext return after Shutdown has been called.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.gamesLock.Lock()
+	if s.shuttingDown {
+		s.gamesLock.Unlock()
+		return ErrServerClosed
+	}
+	s.shuttingDown = true
+	close(s.shutdown)
+	games := make([]*Game, 0, len(s.games))
+	for _, g := range s.games {
+		games = append(games, g)
+	}
+	s.gamesLock.Unlock()
+
+	s.log.Info("Shutting down...")
+
+	if s.killTicker != nil {
+		s.killTicker.Stop()
+	}
+
+	s.listenersLock.Lock()
+	httpServer := s.httpServer
+	tcpListener := s.tcpListener
+	pprofServer := s.pprofServer
+	s.listenersLock.Unlock()
+
+	if pprofServer != nil {
+		pprofServer.Close()
+	}
+
+	if tcpListener != nil {
+		tcpListener.Close()
+	}
+
+	var err error
+	if httpServer != nil {
+		err = httpServer.Shutdown(ctx)
+	}
+
+	for _, g := range games {
+		g.CloseWithReason("server shut down")
+	}
+
+	s.closeRooms()
+	s.disconnectMultiSpectators()
+	s.log.disconnectDebugSockets()
+
+	done := make(chan struct{})
+	go func() {
+		s.gameFuncs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return err
+}
+
+// shutdownWithTimeout calls Shutdown with a context which expires after ShutdownTimeout,
+// so that game functions which never return cannot block Run.
+func (s *Server) shutdownWithTimeout() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+	err := s.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		s.log.Warning("Not all games returned within %s after shutting down.", s.config.ShutdownTimeout)
+	}
+	return err
+}
+
+// closed returns true if Shutdown has been called.
+func (s *Server) closed() bool {
+	s.gamesLock.RLock()
+	defer s.gamesLock.RUnlock()
+	return s.shuttingDown
+}
@@ -0,0 +1,105 @@
+package cg
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrSocketTooSlow is returned when an event cannot be queued because the spectator doesn't keep up with the events.
+var ErrSocketTooSlow = errors.New("socket too slow")
+
+// The time a disconnecting spectator socket has to send its queued messages, e.g. the final events of a closed game.
+const queueFlushTimeout = time.Second
+
+// useSendQueue makes the socket send its messages from a queue of SpectatorQueueSize messages on its own goroutine
+// instead of on the goroutine broadcasting the event. It must be called before the socket is registered anywhere.
+func (s *GameSocket) useSendQueue() {
+	if s.server.config.SpectatorQueueSize <= 0 {
+		return
+	}
+	s.queue = make(chan []byte, s.server.config.SpectatorQueueSize)
+	s.queueDone = make(chan struct{})
+}
+
+// QueueLength returns the number of messages waiting to be sent to the spectator socket.
+func (s *GameSocket) QueueLength() int {
+	return len(s.queue)
+}
+
+// enqueue adds the message to the send queue and evicts the socket if the queue is full.
+func (s *GameSocket) enqueue(message []byte) error {
+	select {
+	case s.queue <- message:
+		return nil
+	default:
+	}
+
+	s.evictOnce.Do(func() {
+		s.logger().Warning("Disconnecting spectator socket %s because it doesn't keep up with %d queued messages.", s.ID, len(s.queue))
+		// the callers of send may hold the locks required to deregister the socket
+		go s.evict()
+	})
+	return ErrSocketTooSlow
+}
+
+// writeQueue sends the queued messages until the socket is disconnected.
+func (s *GameSocket) writeQueue() {
+	defer close(s.queueDone)
+	for {
+		select {
+		case message := <-s.queue:
+			s.deliver(message)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// flushQueue sends the messages left in the queue once writeQueue has returned, e.g. the final events before the game is closed.
+// All writes share a deadline of queueFlushTimeout. Nothing is sent if writeQueue doesn't return within that time
+// or if the socket has been evicted or dropped. It returns false if the connection should not be written to anymore,
+// i.e. in these cases or if a write failed or the deadline passed.
+func (s *GameSocket) flushQueue() bool {
+	if s.queue == nil {
+		return true
+	}
+	deadline := time.Now().Add(queueFlushTimeout)
+	s.flushLock.Lock()
+	skip := s.skipFlush
+	s.flushDeadline = deadline
+	s.flushLock.Unlock()
+	if skip {
+		return false
+	}
+
+	timer := time.NewTimer(queueFlushTimeout)
+	defer timer.Stop()
+	select {
+	case <-s.queueDone:
+	case <-timer.C:
+		return false
+	}
+
+	for time.Now().Before(deadline) {
+		select {
+		case message := <-s.queue:
+			if s.deliver(message) != nil {
+				return false
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// evict closes the connection with a close frame explaining the reason and deregisters the socket.
+func (s *GameSocket) evict() {
+	s.flushLock.Lock()
+	s.skipFlush = true
+	s.flushLock.Unlock()
+	s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow"), time.Now().Add(5*time.Second))
+	s.drop()
+}
@@ -0,0 +1,86 @@
+package cg
+
+import (
+	"fmt"
+)
+
+// EventKeyframe is sent to spectators of the TierKeyframe tier by Game.SendKeyframe.
+const EventKeyframe EventName = "cg_keyframe"
+
+// SpectatorTier is the amount of events a spectator requested with the tier query parameter when connecting.
+type SpectatorTier string
+
+const (
+	// All events are sent to the spectator.
+	TierFull SpectatorTier = "full"
+	// Only the events marked with Game.SetSummaryEvents are sent to the spectator.
+	TierSummary SpectatorTier = "summary"
+	// Only snapshots sent with Game.SendKeyframe are sent to the spectator.
+	TierKeyframe SpectatorTier = "keyframe"
+)
+
+func parseSpectatorTier(tier string) (SpectatorTier, error) {
+	switch SpectatorTier(tier) {
+	case "", TierFull:
+		return TierFull, nil
+	case TierSummary, TierKeyframe:
+		return SpectatorTier(tier), nil
+	default:
+		return "", fmt.Errorf("invalid spectator tier: %s", tier)
+	}
+}
+
+// Tier returns the spectator tier of the socket. Player sockets always receive all events.
+func (s *GameSocket) Tier() SpectatorTier {
+	if s.tier == "" {
+		return TierFull
+	}
+	return s.tier
+}
+
+// SetSummaryEvents marks the events which are sent to spectators of the TierSummary tier.
+func (g *Game) SetSummaryEvents(events ...EventName) {
+	g.summaryEventsLock.Lock()
+	defer g.summaryEventsLock.Unlock()
+	for _, e := range events {
+		g.summaryEvents[e] = struct{}{}
+	}
+}
+
+// SendKeyframe sends a snapshot of the game state with the cg_keyframe event to all spectators of the TierKeyframe tier.
+func (g *Game) SendKeyframe(snapshot any) error {
+	g.spectatorsLock.RLock()
+	defer g.spectatorsLock.RUnlock()
+	var sendErr error
+	for _, s := range g.spectators {
+		if s.Tier() != TierKeyframe {
+			continue
+		}
+		if err := g.sendDataToSpectator(s, EventKeyframe, snapshot); err != nil {
+			sendErr = err
+		}
+	}
+	return sendErr
+}
+
+// acceptsTier returns true if the event belongs to the tier of the socket. Standard cg_* events are sent to every tier.
+func (s *GameSocket) acceptsTier(event EventName) bool {
+	switch s.Tier() {
+	case TierSummary:
+		if isStandardEvent(event) {
+			return true
+		}
+		game := s.game()
+		if game == nil {
+			return true
+		}
+		game.summaryEventsLock.RLock()
+		defer game.summaryEventsLock.RUnlock()
+		_, ok := game.summaryEvents[event]
+		return ok
+	case TierKeyframe:
+		return isStandardEvent(event)
+	default:
+		return true
+	}
+}
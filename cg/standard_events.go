@@ -0,0 +1,110 @@
+package cg
+
+import "time"
+
+// Typed helpers for sending the standard cg_* events with consistent payloads.
+// cg_error, cg_notification and cg_keyframe have their own helpers (SendError, Notify, SendKeyframe)
+// and the cg_room_* events are only sent by rooms.
+
+// SendServerAnnouncement sends a cg_server_announcement event to all players and spectators of the game.
+func (g *Game) SendServerAnnouncement(level NotificationLevel, message string) error {
+	return g.Send(EventServerAnnouncement, ServerAnnouncementEventData{
+		Level:   level,
+		Message: message,
+	})
+}
+
+// SendPlayerDisconnected sends a cg_player_disconnected event about player to all players and spectators of the game.
+func (g *Game) SendPlayerDisconnected(player *Player) error {
+	return g.Send(EventPlayerDisconnected, PlayerDisconnectedEventData{
+		Player: player.ID,
+	})
+}
+
+// SendSettingsChanged sends a cg_settings_changed event with the new config to all players and spectators of the game.
+func (g *Game) SendSettingsChanged(config any) error {
+	return g.Send(EventSettingsChanged, SettingsChangedEventData{
+		Config: config,
+	})
+}
+
+// SendClosing sends a cg_closing event with the time at which the game will be closed to all players and spectators.
+func (g *Game) SendClosing(deadline time.Time) error {
+	return g.Send(EventClosing, ClosingEventData{
+		Deadline: deadline.UnixMilli(),
+	})
+}
+
+// SendClosed sends a cg_closed event with the final results to all players and spectators of the game.
+func (g *Game) SendClosed(results any) error {
+	return g.Send(EventClosed, ClosedEventData{
+		Results: results,
+	})
+}
+
+// SendResync sends a cg_resync event with a snapshot of the game state to the socket.
+func (s *GameSocket) SendResync(snapshot any) error {
+	return s.Send(EventResync, snapshot)
+}
+
+// SendMissedEvents sends a cg_missed_events event with events to the socket.
+func (s *GameSocket) SendMissedEvents(events []Event) error {
+	return s.Send(EventMissedEvents, MissedEventsEventData{
+		Events: events,
+	})
+}
+
+// SendAuthenticated sends a cg_authenticated event with the ID of the socket to the socket.
+func (s *GameSocket) SendAuthenticated() error {
+	return s.Send(EventAuthenticated, AuthenticatedEventData{
+		Socket: s.ID,
+	})
+}
+
+// SendAuthenticationFailed sends a cg_authentication_failed event with the reason to the socket.
+func (s *GameSocket) SendAuthenticationFailed(message string) error {
+	return s.Send(EventAuthenticationFailed, AuthenticationFailedEventData{
+		Message: message,
+	})
+}
+
+// SendVoteStarted sends a cg_vote_started event about the vote to all players and spectators of the game.
+func (g *Game) SendVoteStarted(vote *Vote, deadline time.Time) error {
+	return g.Send(EventVoteStarted, VoteStartedEventData{
+		Vote:     vote.ID,
+		Question: vote.Question,
+		Options:  vote.Options,
+		Deadline: deadline.UnixMilli(),
+	})
+}
+
+// SendVoteEnded sends a cg_vote_ended event with the result of a vote to all players and spectators of the game.
+func (g *Game) SendVoteEnded(result VoteEndedEventData) error {
+	return g.Send(EventVoteEnded, result)
+}
+
+// SendTimer sends a cg_timer event with the state of the timer to the player.
+func (p *Player) SendTimer(name string, state TimerState, remaining time.Duration) error {
+	return p.Send(EventTimer, TimerEventData{
+		Name:      name,
+		State:     state,
+		Remaining: remaining.Milliseconds(),
+	})
+}
+
+// sendInvite sends the cg_invite event to the recipient of the invite.
+func (i *Invite) sendInvite() error {
+	return i.send(EventInvite, InviteEventData{
+		Invite: i.ID,
+		GameID: i.Game.ID,
+	})
+}
+
+// sendInviteAccepted sends the cg_invite_accepted event with the credentials in the game to the recipient of the invite.
+func (i *Invite) sendInviteAccepted(playerID, playerSecret string) error {
+	return i.send(EventInviteAccepted, InviteAcceptedEventData{
+		GameID:       i.Game.ID,
+		PlayerID:     playerID,
+		PlayerSecret: playerSecret,
+	})
+}
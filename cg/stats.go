@@ -0,0 +1,95 @@
+package cg
+
+import "time"
+
+// gameStats counts the events broadcast, the commands processed and the reconnects of a game.
+type gameStats struct {
+	eventsBroadcast   int64
+	commandsProcessed int64
+	// the sockets connected by players after their first one
+	reconnects int64
+	// the missed events and their bytes sent to reconnecting players
+	replayedEvents int64
+	replayedBytes  int64
+}
+
+func (g *Game) countBroadcast() {
+	g.statsLock.Lock()
+	g.stats.eventsBroadcast++
+	g.statsLock.Unlock()
+}
+
+func (g *Game) countCommand() {
+	g.statsLock.Lock()
+	g.stats.commandsProcessed++
+	g.statsLock.Unlock()
+}
+
+func (g *Game) countReconnect() {
+	g.statsLock.Lock()
+	g.stats.reconnects++
+	g.statsLock.Unlock()
+}
+
+func (g *Game) countReplay(events, bytes int) {
+	g.statsLock.Lock()
+	g.stats.replayedEvents += int64(events)
+	g.stats.replayedBytes += int64(bytes)
+	g.statsLock.Unlock()
+}
+
+// CreatedAt returns the time at which the game was created.
+func (g *Game) CreatedAt() time.Time {
+	return g.createdAt
+}
+
+// Uptime returns the time since the game was created.
+func (g *Game) Uptime() time.Duration {
+	return g.server.config.Clock.Now().Sub(g.createdAt)
+}
+
+// EventsBroadcast returns the number of events sent to all players and spectators with Send.
+func (g *Game) EventsBroadcast() int64 {
+	g.statsLock.Lock()
+	defer g.statsLock.Unlock()
+	return g.stats.eventsBroadcast
+}
+
+// CommandsProcessed returns the number of player commands the game loop has taken from the command queue.
+func (g *Game) CommandsProcessed() int64 {
+	g.statsLock.Lock()
+	defer g.statsLock.Unlock()
+	return g.stats.commandsProcessed
+}
+
+// Reconnects returns the number of sockets players connected after their first one.
+func (g *Game) Reconnects() int64 {
+	g.statsLock.Lock()
+	defer g.statsLock.Unlock()
+	return g.stats.reconnects
+}
+
+// ReplayedEvents returns the number and total size in bytes of the missed events sent to reconnecting players.
+func (g *Game) ReplayedEvents() (events int64, bytes int64) {
+	g.statsLock.Lock()
+	defer g.statsLock.Unlock()
+	return g.stats.replayedEvents, g.stats.replayedBytes
+}
+
+// MissedEvents returns the number of events currently queued for players without sockets.
+func (g *Game) MissedEvents() int {
+	count := 0
+	g.ForEachPlayer(func(player *Player) {
+		player.missedEventsLock.RLock()
+		count += len(player.missedEvents)
+		player.missedEventsLock.RUnlock()
+	})
+	return count
+}
+
+// SpectatorCount returns the number of sockets currently spectating the game.
+func (g *Game) SpectatorCount() int {
+	g.spectatorsLock.RLock()
+	defer g.spectatorsLock.RUnlock()
+	return len(g.spectators)
+}
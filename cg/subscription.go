@@ -0,0 +1,64 @@
+package cg
+
+import "strings"
+
+const (
+	// CommandSubscribe limits the events sent to the socket to the subscribed events.
+	// Standard cg_* events are always sent.
+	CommandSubscribe CommandName = "cg_subscribe"
+	// CommandUnsubscribe removes events from the subscriptions of the socket.
+	// Without any events the socket receives all events again.
+	CommandUnsubscribe CommandName = "cg_unsubscribe"
+)
+
+type SubscriptionCommandData struct {
+	Events []EventName `json:"events"`
+}
+
+func (s *GameSocket) handleSubscription(cmd Command) error {
+	var data SubscriptionCommandData
+	err := cmd.UnmarshalData(&data)
+	if err != nil {
+		return err
+	}
+
+	s.subscriptionsLock.Lock()
+	defer s.subscriptionsLock.Unlock()
+
+	if cmd.Name == CommandSubscribe {
+		if s.subscriptions == nil {
+			s.subscriptions = make(map[EventName]struct{}, len(data.Events))
+		}
+		for _, e := range data.Events {
+			s.subscriptions[e] = struct{}{}
+		}
+		return nil
+	}
+
+	if len(data.Events) == 0 {
+		s.subscriptions = nil
+		return nil
+	}
+	for _, e := range data.Events {
+		delete(s.subscriptions, e)
+	}
+	return nil
+}
+
+func (s *GameSocket) isSubscribed(event EventName) bool {
+	if isStandardEvent(event) {
+		return true
+	}
+	s.subscriptionsLock.RLock()
+	defer s.subscriptionsLock.RUnlock()
+	if s.subscriptions == nil {
+		return true
+	}
+	_, ok := s.subscriptions[event]
+	return ok
+}
+
+// isStandardEvent returns true for the cg_* events defined by the CodeGame protocol.
+func isStandardEvent(event EventName) bool {
+	return strings.HasPrefix(string(event), "cg_")
+}
@@ -0,0 +1,266 @@
+package cg
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// CommandAuthenticate must be the first line sent over a TCP connection.
+	CommandAuthenticate CommandName = "cg_authenticate"
+
+	EventAuthenticated        EventName = "cg_authenticated"
+	EventAuthenticationFailed EventName = "cg_authentication_failed"
+)
+
+// The maximum length of a single line sent by a TCP client.
+const maxTCPLineLength = 1 << 20
+
+// The time a TCP client has to send its cg_authenticate command after connecting.
+const tcpAuthenticationTimeout = 10 * time.Second
+
+// The maximum delay between retries of Accept after temporary errors, e.g. when the process runs out of file descriptors.
+const maxTCPAcceptDelay = time.Second
+
+type AuthenticateCommandData struct {
+	// The CodeGame protocol version of the client (empty => not checked).
+	CGVersion string `json:"cg_version"`
+	GameID    string `json:"game_id"`
+	// Connect as a spectator instead of a player.
+	Spectate bool `json:"spectate"`
+	// The spectator tier: full, summary or keyframe. (default: full)
+	Tier         SpectatorTier `json:"tier"`
+	PlayerID     string        `json:"player_id"`
+	PlayerSecret string        `json:"player_secret"`
+}
+
+type AuthenticatedEventData struct {
+	Socket string `json:"socket"`
+}
+
+type AuthenticationFailedEventData struct {
+	Message string `json:"message"`
+}
+
+// socketConn is the connection of a GameSocket. It is implemented by *websocket.Conn and tcpConn.
+type socketConn interface {
+	ReadMessage() (int, []byte, error)
+	WriteMessage(messageType int, data []byte) error
+	WriteControl(messageType int, data []byte, deadline time.Time) error
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+	SetPongHandler(h func(appData string) error)
+	SetCloseHandler(h func(code int, text string) error)
+	Close() error
+}
+
+// tcpConn speaks newline-delimited JSON over a plain TCP connection.
+// There are no control frames, so pings are answered locally and liveness relies on TCP keep-alive.
+type tcpConn struct {
+	conn    net.Conn
+	scanner *bufio.Scanner
+
+	pongLock    sync.Mutex
+	pongHandler func(appData string) error
+}
+
+func newTCPConn(conn net.Conn) *tcpConn {
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), maxTCPLineLength)
+	return &tcpConn{
+		conn:    conn,
+		scanner: scanner,
+	}
+}
+
+func (c *tcpConn) ReadMessage() (int, []byte, error) {
+	for c.scanner.Scan() {
+		line := c.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		msg := make([]byte, len(line))
+		copy(msg, line)
+		return websocket.TextMessage, msg, nil
+	}
+	if err := c.scanner.Err(); err != nil {
+		return 0, nil, err
+	}
+	return 0, nil, net.ErrClosed
+}
+
+func (c *tcpConn) WriteMessage(messageType int, data []byte) error {
+	// data may be shared with other sockets and must not be modified by append
+	line := make([]byte, len(data)+1)
+	copy(line, data)
+	line[len(data)] = '\n'
+	_, err := c.conn.Write(line)
+	return err
+}
+
+func (c *tcpConn) WriteControl(messageType int, data []byte, deadline time.Time) error {
+	if messageType == websocket.PingMessage {
+		c.pongLock.Lock()
+		handler := c.pongHandler
+		c.pongLock.Unlock()
+		if handler != nil {
+			return handler(string(data))
+		}
+	}
+	return nil
+}
+
+func (c *tcpConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *tcpConn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+func (c *tcpConn) SetPongHandler(h func(appData string) error) {
+	c.pongLock.Lock()
+	c.pongHandler = h
+	c.pongLock.Unlock()
+}
+
+// SetCloseHandler does nothing because there are no close frames.
+func (c *tcpConn) SetCloseHandler(h func(code int, text string) error) {}
+
+func (c *tcpConn) Close() error {
+	return c.conn.Close()
+}
+
+// ServeTCP accepts connections on l which speak newline-delimited JSON instead of websockets,
+// e.g. for microcontrollers without a websocket library.
+// The first line of every connection must be a cg_authenticate command. Afterwards events and commands
+// are exchanged like on the connect and spectate websocket endpoints, one JSON object per line.
+// Like net/http, temporary errors of Accept are retried with an increasing delay.
+func (s *Server) ServeTCP(l net.Listener) error {
+	var delay time.Duration
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Temporary() {
+				if delay == 0 {
+					delay = 5 * time.Millisecond
+				} else {
+					delay *= 2
+				}
+				if delay > maxTCPAcceptDelay {
+					delay = maxTCPAcceptDelay
+				}
+				s.log.Error("Failed to accept TCP connection: %s. Retrying in %s.", err, delay)
+				select {
+				case <-time.After(delay):
+					continue
+				case <-s.shutdown:
+				}
+			}
+			return err
+		}
+		delay = 0
+		go s.handleTCPConnection(conn)
+	}
+}
+
+func (s *Server) handleTCPConnection(netConn net.Conn) {
+	conn := newTCPConn(netConn)
+	socket := s.newGameSocket(conn, netConn.RemoteAddr().String(), "")
+
+	conn.SetReadDeadline(time.Now().Add(tcpAuthenticationTimeout))
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	err = s.authenticateTCPSocket(socket, msg)
+	if err != nil {
+		socket.SendAuthenticationFailed(err.Error())
+		conn.Close()
+		return
+	}
+}
+
+// sendAuthenticated confirms the authentication of a TCP socket if it has been requested.
+// It is called after the socket has been added and before any game events are sent to it.
+func (s *GameSocket) sendAuthenticated() {
+	if s.confirmAuthentication {
+		s.SendAuthenticated()
+	}
+}
+
+func (s *Server) authenticateTCPSocket(socket *GameSocket, msg []byte) error {
+	var cmd Command
+	err := json.Unmarshal(msg, &cmd)
+	if err != nil || cmd.Name != CommandAuthenticate {
+		return fmt.Errorf("expected '%s' command", CommandAuthenticate)
+	}
+
+	var data AuthenticateCommandData
+	err = cmd.UnmarshalData(&data)
+	if err != nil {
+		return err
+	}
+
+	if data.CGVersion != "" && !IsCompatible(data.CGVersion) {
+		return fmt.Errorf("CodeGame version %s is not supported by this server", data.CGVersion)
+	}
+
+	game, ok := s.getGame(data.GameID)
+	if !ok {
+		return ErrGameNotFound
+	}
+
+	if data.Spectate {
+		socket.tier, err = parseSpectatorTier(string(data.Tier))
+		if err != nil {
+			return err
+		}
+		socket.confirmAuthentication = true
+		socket.useSendQueue()
+		err = game.addSpectator(socket)
+		if err != nil {
+			return err
+		}
+		game.Log.TraceData(socket.info(), "New TCP spectator socket connected with id %s.", socket.ID)
+		go socket.handleConnection()
+		return nil
+	}
+
+	player, ok := game.GetPlayer(data.PlayerID)
+	if !ok {
+		return errors.New("player not found")
+	}
+
+	if err := s.checkSecretFormat(data.PlayerSecret); err != nil {
+		return err
+	}
+	if player.Secret != data.PlayerSecret {
+		return errors.New("wrong player secret")
+	}
+
+	// missed events are sent by addSocket and must follow the confirmation
+	socket.confirmAuthentication = true
+	err = player.addSocket(socket)
+	if err != nil {
+		return err
+	}
+	player.Log.TraceData(socket.info(), "New TCP socket connected with id %s.", socket.ID)
+
+	go socket.handleConnection()
+
+	if game.OnPlayerSocketConnected != nil {
+		game.OnPlayerSocketConnected(player, socket)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package cg
+
+import "time"
+
+// The number of consecutive ticks without load after which a lowered tick rate is raised again.
+const tickRecoveryTicks = 10
+
+// TickConfig configures the game loop run by RunTicks.
+type TickConfig struct {
+	// The number of ticks per second while the server keeps up.
+	Rate float64
+	// The lowest number of ticks per second the rate is reduced to while the queues are saturated. (default: Rate / 4)
+	MinRate float64
+	// The fill ratio of the command queue or the fullest spectator send queue at which the tick rate is halved. (default: 0.5)
+	Saturation float64
+	// OnRateChanged is called on the game loop with the new number of ticks per second whenever the rate is adapted.
+	OnRateChanged func(rate float64)
+}
+
+// RunTicks runs a tick-driven game loop until the game is closed.
+// Before every tick all queued commands are passed to onCommand. onTick is called with the time since the last tick.
+// While the command queue or the send queues of the spectators are saturated the tick rate is halved down to MinRate
+// and restored once the load drops, so an overloaded server does not fall further behind.
+func (g *Game) RunTicks(config TickConfig, onCommand func(cmd CommandWrapper), onTick func(dt time.Duration)) {
+	if config.Rate <= 0 {
+		panic("tick rate must be positive")
+	}
+	if config.MinRate <= 0 || config.MinRate > config.Rate {
+		config.MinRate = config.Rate / 4
+	}
+	if config.Saturation <= 0 {
+		config.Saturation = 0.5
+	}
+
+	clock := g.server.config.Clock
+	rate := config.Rate
+	idleTicks := 0
+	last := clock.Now()
+	tick := make(chan struct{}, 1)
+	for {
+		load := g.load()
+		newRate := rate
+		if load >= config.Saturation {
+			idleTicks = 0
+			newRate = rate / 2
+			if newRate < config.MinRate {
+				newRate = config.MinRate
+			}
+		} else if rate < config.Rate && load < config.Saturation/4 {
+			idleTicks++
+			if idleTicks >= tickRecoveryTicks {
+				idleTicks = 0
+				newRate = rate * 2
+				if newRate > config.Rate {
+					newRate = config.Rate
+				}
+			}
+		}
+		if newRate != rate {
+			g.Log.Warning("Changing the tick rate from %.1f to %.1f (load: %.2f).", rate, newRate, load)
+			rate = newRate
+			if config.OnRateChanged != nil {
+				config.OnRateChanged(rate)
+			}
+		}
+
+		for {
+			cmd, ok := g.NextCommand()
+			if !ok {
+				break
+			}
+			onCommand(cmd)
+		}
+		if !g.Running() {
+			return
+		}
+
+		now := clock.Now()
+		onTick(now.Sub(last))
+		last = now
+
+		clock.AfterFunc(time.Duration(float64(time.Second)/rate), func() {
+			tick <- struct{}{}
+		})
+		select {
+		case <-tick:
+		case <-g.closing:
+			return
+		}
+	}
+}
+
+// load returns the fill ratio of the command queue or the fullest spectator send queue.
+func (g *Game) load() float64 {
+	load := float64(len(g.cmdChan)) / float64(cap(g.cmdChan))
+
+	size := g.server.config.SpectatorQueueSize
+	if size <= 0 {
+		return load
+	}
+	g.spectatorsLock.RLock()
+	defer g.spectatorsLock.RUnlock()
+	for _, s := range g.spectators {
+		if l := float64(s.QueueLength()) / float64(size); l > load {
+			load = l
+		}
+	}
+	return load
+}
@@ -0,0 +1,51 @@
+package cg
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Bananenpro/log"
+)
+
+var ErrNoCertificate = errors.New("no TLS certificate configured")
+
+// RunTLS starts the webserver like Run but serves HTTPS and wss:// connections with the certificate configured with
+// TLSCertFile and TLSKeyFile or TLSConfig. The TCP listener uses TLS as well.
+func (s *Server) RunTLS(runGameFunc func(game *Game, config json.RawMessage)) {
+	err := s.RunTLSContext(context.Background(), runGameFunc)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// RunTLSContext starts the webserver like RunTLS but returns the error of a failing listener like RunContext.
+func (s *Server) RunTLSContext(ctx context.Context, runGameFunc func(game *Game, config json.RawMessage)) error {
+	return s.run(ctx, runGameFunc, true, nil)
+}
+
+// tlsConfig returns a copy of TLSConfig with the certificate files loaded.
+func (s *Server) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{}
+	if s.config.TLSConfig != nil {
+		config = s.config.TLSConfig.Clone()
+	}
+
+	if s.config.TLSCertFile != "" || s.config.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(s.config.TLSCertFile, s.config.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load TLS certificate: %w", err)
+		}
+		config.Certificates = append(config.Certificates, cert)
+	}
+
+	if len(config.Certificates) == 0 && config.GetCertificate == nil {
+		return nil, ErrNoCertificate
+	}
+	if config.MinVersion == 0 {
+		config.MinVersion = tls.VersionTLS12
+	}
+	return config, nil
+}
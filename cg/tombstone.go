@@ -0,0 +1,210 @@
+package cg
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// The number of recently closed games remembered for 410 Gone responses.
+const maxTombstones = 256
+
+// tombstone describes a recently closed game.
+type tombstone struct {
+	id       string
+	reason   string
+	closedAt time.Time
+	results  any
+	// the players in the game when it was closed
+	playerIDs map[string]struct{}
+}
+
+// CloseWithReason closes the game like Close. Requests for the game within a short time afterwards
+// are answered with 410 Gone and the reason, e.g. "match finished".
+func (g *Game) CloseWithReason(reason string) error {
+	g.closingLock.Lock()
+	if g.closeReason == "" {
+		g.closeReason = reason
+	}
+	g.closingLock.Unlock()
+	return g.Close()
+}
+
+// addTombstone remembers the closed game and evicts the least recently closed game if there are more than maxTombstones.
+func (s *Server) addTombstone(game *Game) {
+	game.closingLock.Lock()
+	t := &tombstone{
+		id:       game.ID,
+		reason:   game.closeReason,
+		closedAt: s.config.Clock.Now(),
+		results:  game.results,
+	}
+	game.closingLock.Unlock()
+	t.playerIDs = make(map[string]struct{})
+	game.ForEachPlayer(func(player *Player) {
+		t.playerIDs[player.ID] = struct{}{}
+	})
+	if t.reason == "" {
+		t.reason = "closed by the game"
+	}
+
+	s.tombstonesLock.Lock()
+	defer s.tombstonesLock.Unlock()
+	if e, ok := s.tombstones[t.id]; ok {
+		s.tombstoneOrder.Remove(e)
+	}
+	s.tombstones[t.id] = s.tombstoneOrder.PushFront(t)
+	for s.tombstoneOrder.Len() > maxTombstones {
+		oldest := s.tombstoneOrder.Back()
+		s.tombstoneOrder.Remove(oldest)
+		delete(s.tombstones, oldest.Value.(*tombstone).id)
+	}
+}
+
+func (s *Server) getTombstone(gameID string) (*tombstone, bool) {
+	s.tombstonesLock.Lock()
+	defer s.tombstonesLock.Unlock()
+	e, ok := s.tombstones[gameID]
+	if !ok {
+		return nil, false
+	}
+	return e.Value.(*tombstone), true
+}
+
+// purgeTombstoneResults removes the player from the recently closed games it was in and its entries from their results.
+// It returns true if the player was in any of them.
+func (s *Server) purgeTombstoneResults(playerID string) bool {
+	s.tombstonesLock.Lock()
+	defer s.tombstonesLock.Unlock()
+	found := false
+	for e := s.tombstoneOrder.Front(); e != nil; e = e.Next() {
+		t := e.Value.(*tombstone)
+		if _, ok := t.playerIDs[playerID]; !ok {
+			continue
+		}
+		// tombstones are replaced instead of modified because they are read without holding tombstonesLock
+		purged := *t
+		purged.playerIDs = make(map[string]struct{}, len(t.playerIDs)-1)
+		for id := range t.playerIDs {
+			if id != playerID {
+				purged.playerIDs[id] = struct{}{}
+			}
+		}
+		if results, changed := removePlayerFromResults(t.results, playerID); changed {
+			purged.results = results
+		}
+		e.Value = &purged
+		found = true
+	}
+	return found
+}
+
+// removePlayerFromResults returns a copy of the JSON representation of results without the members keyed by playerID,
+// the members whose value is playerID and the array elements which are playerID or objects with such a member.
+// The server doesn't know the structure of the results, so data which doesn't refer to the player by its ID is kept.
+// It returns false if nothing has been removed.
+func removePlayerFromResults(results any, playerID string) (any, bool) {
+	if results == nil {
+		return nil, false
+	}
+	data, err := json.Marshal(results)
+	if err != nil {
+		return nil, true
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	err = decoder.Decode(&value)
+	if err != nil {
+		return nil, true
+	}
+	return removePlayerID(value, playerID)
+}
+
+func removePlayerID(value any, playerID string) (any, bool) {
+	changed := false
+	switch v := value.(type) {
+	case map[string]any:
+		for key, member := range v {
+			if key == playerID || member == playerID {
+				delete(v, key)
+				changed = true
+				continue
+			}
+			var memberChanged bool
+			v[key], memberChanged = removePlayerID(member, playerID)
+			changed = changed || memberChanged
+		}
+	case []any:
+		elements := v[:0]
+		for _, element := range v {
+			if refersToPlayer(element, playerID) {
+				changed = true
+				continue
+			}
+			element, elementChanged := removePlayerID(element, playerID)
+			changed = changed || elementChanged
+			elements = append(elements, element)
+		}
+		value = elements
+	}
+	return value, changed
+}
+
+// refersToPlayer returns true if value is playerID or an object with a member whose value is playerID, e.g. {"player": playerID, "score": 3}.
+func refersToPlayer(value any, playerID string) bool {
+	if value == playerID {
+		return true
+	}
+	object, ok := value.(map[string]any)
+	if !ok {
+		return false
+	}
+	for _, member := range object {
+		if member == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+// sendGameNotFound responds with 410 Gone if the game has been closed recently and 404 Not Found otherwise.
+func (s *Server) sendGameNotFound(w http.ResponseWriter, gameID string) {
+	t, ok := s.getTombstone(gameID)
+	if !ok {
+		send(w, http.StatusNotFound, "game not found")
+		return
+	}
+
+	type response struct {
+		Message string `json:"message"`
+		Reason  string `json:"reason"`
+		// The time at which the game was closed in unix milliseconds.
+		ClosedAt int64 `json:"closed_at"`
+		// The URL of the final results of the game (empty => no results).
+		Results string `json:"results,omitempty"`
+	}
+	res := response{
+		Message:  "game closed",
+		Reason:   t.reason,
+		ClosedAt: t.closedAt.UnixMilli(),
+	}
+	if t.results != nil {
+		res.Results = "/api/games/" + t.id + "/results"
+	}
+	sendJSON(w, http.StatusGone, res)
+}
+
+// resultsEndpoint returns the final results of a recently closed game which was closed with CloseGracefully.
+func (s *Server) resultsEndpoint(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameId")
+	t, ok := s.getTombstone(gameID)
+	if !ok || t.results == nil {
+		send(w, http.StatusNotFound, "no results found")
+		return
+	}
+	sendJSON(w, http.StatusOK, t.results)
+}
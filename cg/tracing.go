@@ -0,0 +1,74 @@
+package cg
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Tracer creates spans for the HTTP requests and commands handled by the server.
+// It can be implemented with a few lines on top of an OpenTelemetry tracer to export the spans with any OpenTelemetry exporter.
+type Tracer interface {
+	// Start starts a span which is a child of the span in ctx if there is one.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a unit of work started by a Tracer.
+type Span interface {
+	SetAttribute(key string, value any)
+	SetError(err error)
+	End()
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value any) {}
+func (noopSpan) SetError(err error)                 {}
+func (noopSpan) End()                               {}
+
+// startSpan starts a span with the configured Tracer or returns a span which does nothing.
+func (s *Server) startSpan(ctx context.Context, name string) (context.Context, Span) {
+	if s.config.Tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return s.config.Tracer.Start(ctx, name)
+}
+
+// traceRequests creates a span for every HTTP request with its route, e.g. "/api/games/{gameId}", method and status as attributes.
+func (s *Server) traceRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.config.Tracer == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, span := s.startSpan(r.Context(), "HTTP "+r.Method)
+		defer span.End()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			span.SetAttribute("http.route", rctx.RoutePattern())
+		}
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.status_code", ww.Status())
+	})
+}
+
+// traceCommand starts the span of a command sent by the player which ends when the game loop takes it from the command queue.
+func (p *Player) traceCommand(cmd Command) Span {
+	_, span := p.server.startSpan(context.Background(), "command "+string(cmd.Name))
+	span.SetAttribute("game.id", p.game.ID)
+	span.SetAttribute("player.id", p.ID)
+	return span
+}
+
+// endSpan ends the span of the command if there is one.
+func (w CommandWrapper) endSpan() {
+	if w.span != nil {
+		w.span.End()
+	}
+}
@@ -0,0 +1,64 @@
+package cg
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// TrialConfig contains the stricter limits of games created by unauthenticated requests.
+type TrialConfig struct {
+	// The time after which trial games are closed. (0 => never)
+	TTL time.Duration
+	// The maximum number of players per trial game. (0 => MaxPlayersPerGame)
+	MaxPlayers int
+	// The maximum number of concurrent trial games. (0 => only limited by MaxGames)
+	MaxGames int
+}
+
+var ErrMaxTrialGamesReached = fmt.Errorf("%w for trial games", ErrMaxGamesReached)
+
+// isTrialRequest returns true if games created by r are trial games.
+func (s *Server) isTrialRequest(r *http.Request) bool {
+	return s.config.AuthenticateRequest != nil && !s.config.AuthenticateRequest(r)
+}
+
+// Trial returns true if the game has been created by an unauthenticated request and is subject to the TrialGames limits.
+func (g *Game) Trial() bool {
+	return g.trial
+}
+
+// trialGameCount returns the number of running trial games. The caller must hold gamesLock.
+func (s *Server) trialGameCount() int {
+	count := 0
+	for _, g := range s.games {
+		if g.trial {
+			count++
+		}
+	}
+	return count
+}
+
+// maxPlayers returns the maximum number of players of the game (0 => unlimited).
+func (g *Game) maxPlayers() int {
+	limit := g.server.config.MaxPlayersPerGame
+	if trialLimit := g.server.config.TrialGames.MaxPlayers; g.trial && trialLimit > 0 && (limit == 0 || trialLimit < limit) {
+		limit = trialLimit
+	}
+	return limit
+}
+
+// expireTrial closes the trial game after TrialGames.TTL.
+func (g *Game) expireTrial() {
+	ttl := g.server.config.TrialGames.TTL
+	if !g.trial || ttl <= 0 {
+		return
+	}
+	g.server.config.Clock.AfterFunc(ttl, func() {
+		if !g.Running() {
+			return
+		}
+		g.server.log.Info("Closing trial game %s after %s.", g.ID, ttl)
+		g.CloseWithReason("trial expired")
+	})
+}
@@ -0,0 +1,282 @@
+/*
+Package turns manages the turn order of turn-based CodeGame games.
+*/
+package turns
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/code-game-project/go-server/cg"
+)
+
+// EventTurn is sent to all players and spectators whenever a new turn begins.
+const EventTurn cg.EventName = "cg_turn"
+
+var ErrNotYourTurn = cg.NewError(cg.ErrorNotYourTurn, "not your turn")
+
+type TurnEventData struct {
+	// The ID of the player whose turn it is.
+	Player string `json:"player"`
+	// The number of the turn starting with 1.
+	Turn int `json:"turn"`
+	// The number of the round starting with 1.
+	Round int `json:"round"`
+	// The time at which the turn times out in unix milliseconds (0 => no time limit).
+	Deadline int64 `json:"deadline,omitempty"`
+}
+
+type TimeoutAction int
+
+const (
+	// The player loses the current turn.
+	Skip TimeoutAction = iota
+	// The player is removed from the turn order.
+	Forfeit
+)
+
+type Config struct {
+	// The time a player has for a turn. (0 => unlimited)
+	TurnDuration time.Duration
+	// What happens to a player who lets the turn time run out. (default: Skip)
+	TimeoutAction TimeoutAction
+	// Next returns the index of the player in order who takes the next turn. (default: round robin)
+	// A new round starts whenever the returned index is not greater than current.
+	Next func(order []*cg.Player, current int) int
+	// The commands which may only be sent by the player whose turn it is (empty => all commands).
+	TurnCommands []cg.CommandName
+}
+
+type Manager struct {
+	// TimedOut is called after a player let the turn time run out.
+	// It is called from the goroutine of the timer.
+	TimedOut func(player *cg.Player)
+
+	game   *cg.Game
+	config Config
+
+	lock    sync.RWMutex
+	order   []*cg.Player
+	current int
+	turn    int
+	round   int
+	timer   cg.Timer
+	running bool
+}
+
+// New creates a turn manager for the game.
+func New(game *cg.Game, config Config) *Manager {
+	if config.Next == nil {
+		config.Next = RoundRobin
+	}
+	return &Manager{
+		game:   game,
+		config: config,
+	}
+}
+
+// RoundRobin gives every player a turn in the order they were added.
+func RoundRobin(order []*cg.Player, current int) int {
+	return (current + 1) % len(order)
+}
+
+// AddPlayer appends the player to the turn order.
+func (m *Manager) AddPlayer(player *cg.Player) {
+	m.lock.Lock()
+	m.order = append(m.order, player)
+	m.lock.Unlock()
+}
+
+// RemovePlayer removes the player from the turn order.
+// If it is currently the turn of the player, the next turn begins.
+func (m *Manager) RemovePlayer(player *cg.Player) {
+	m.lock.Lock()
+	next := m.remove(player)
+	m.lock.Unlock()
+	m.send(next)
+}
+
+// Start begins the first turn with the first player in the turn order.
+func (m *Manager) Start() error {
+	m.lock.Lock()
+	if len(m.order) == 0 {
+		m.lock.Unlock()
+		return errors.New("no players in turn order")
+	}
+	m.running = true
+	m.turn = 0
+	m.round = 1
+	next := m.begin(0)
+	m.lock.Unlock()
+	m.send(next)
+	return nil
+}
+
+// Stop stops the turn timer. No more turns begin until Start is called again.
+func (m *Manager) Stop() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.running = false
+	m.stopTimer()
+}
+
+// Current returns the player whose turn it is or nil if no turn is active.
+func (m *Manager) Current() *cg.Player {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if !m.running {
+		return nil
+	}
+	return m.order[m.current]
+}
+
+// Turn returns the number of the current turn and round.
+func (m *Manager) Turn() (int, int) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.turn, m.round
+}
+
+// IsTurn returns true if it is currently the turn of the player.
+func (m *Manager) IsTurn(player *cg.Player) bool {
+	return m.Current() == player
+}
+
+// EndTurn ends the current turn and begins the turn of the next player.
+func (m *Manager) EndTurn() {
+	m.lock.Lock()
+	var next *TurnEventData
+	if m.running {
+		next = m.advance()
+	}
+	m.lock.Unlock()
+	m.send(next)
+}
+
+// ValidateCommand returns ErrNotYourTurn if the command is a turn command and it is not the turn of player.
+// It can be used as the CommandValidator of the game.
+func (m *Manager) ValidateCommand(player *cg.Player, cmd cg.Command) error {
+	if len(m.config.TurnCommands) > 0 {
+		isTurnCommand := false
+		for _, name := range m.config.TurnCommands {
+			if name == cmd.Name {
+				isTurnCommand = true
+				break
+			}
+		}
+		if !isTurnCommand {
+			return nil
+		}
+	}
+
+	if !m.IsTurn(player) {
+		return ErrNotYourTurn
+	}
+	return nil
+}
+
+// begin starts the turn of the player at index and returns the cg_turn event data,
+// which is sent with send after releasing the lock.
+func (m *Manager) begin(index int) *TurnEventData {
+	m.stopTimer()
+	m.current = index
+	m.turn++
+
+	data := &TurnEventData{
+		Player: m.order[index].ID,
+		Turn:   m.turn,
+		Round:  m.round,
+	}
+
+	if m.config.TurnDuration > 0 {
+		clock := m.game.Clock()
+		data.Deadline = clock.Now().Add(m.config.TurnDuration).UnixMilli()
+		turn := m.turn
+		m.timer = clock.AfterFunc(m.config.TurnDuration, func() {
+			m.timeout(turn)
+		})
+	}
+
+	return data
+}
+
+func (m *Manager) send(data *TurnEventData) {
+	if data != nil {
+		m.game.Send(EventTurn, *data)
+	}
+}
+
+func (m *Manager) advance() *TurnEventData {
+	next := m.config.Next(m.order, m.current)
+	if next <= m.current {
+		m.round++
+	}
+	return m.begin(next)
+}
+
+// remove removes the player from the turn order and returns the data of the turn which began as a result, if any.
+func (m *Manager) remove(player *cg.Player) *TurnEventData {
+	index := -1
+	for i, p := range m.order {
+		if p == player {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil
+	}
+
+	m.order = append(m.order[:index], m.order[index+1:]...)
+	if !m.running {
+		return nil
+	}
+
+	if len(m.order) == 0 {
+		m.running = false
+		m.stopTimer()
+		return nil
+	}
+
+	if index < m.current {
+		m.current--
+	} else if index == m.current {
+		// continue with the player who took the place of the removed player
+		if index >= len(m.order) {
+			// the removed player was the last one in the order
+			index = 0
+			m.round++
+		}
+		return m.begin(index)
+	}
+	return nil
+}
+
+func (m *Manager) timeout(turn int) {
+	m.lock.Lock()
+	if !m.running || m.turn != turn {
+		m.lock.Unlock()
+		return
+	}
+	player := m.order[m.current]
+	var next *TurnEventData
+	if m.config.TimeoutAction == Forfeit {
+		next = m.remove(player)
+	} else {
+		next = m.advance()
+	}
+	m.lock.Unlock()
+	m.send(next)
+
+	if m.TimedOut != nil {
+		m.TimedOut(player)
+	}
+}
+
+func (m *Manager) stopTimer() {
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+}
@@ -0,0 +1,42 @@
+package cg
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+//go:embed ui
+var uiFiles embed.FS
+
+var uiTemplates = template.Must(template.ParseFS(uiFiles, "ui/*.html"))
+
+func (s *Server) uiRoutes(r chi.Router) {
+	if !s.config.EnableWebUI {
+		return
+	}
+	r.Get("/lobby", s.serveUIFile("lobby.html"))
+	r.Get("/admin", s.serveUIFile("admin.html"))
+}
+
+// serveUIFile executes the template of the UI page with the path of the API, which differs for the game types of a MultiServer.
+func (s *Server) serveUIFile(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		err := uiTemplates.ExecuteTemplate(&buf, name, struct {
+			APIPath string
+		}{
+			APIPath: s.apiPath,
+		})
+		if err != nil {
+			s.log.Error("Failed to render '%s': %s", name, err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(buf.Bytes())
+	}
+}
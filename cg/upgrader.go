@@ -0,0 +1,36 @@
+package cg
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// UpgraderConfig configures the websocket handshakes of the server.
+type UpgraderConfig struct {
+	// CheckOrigin returns true if a websocket connection from the origin of r is allowed. (default: the origins allowed by CORS)
+	CheckOrigin func(r *http.Request) bool
+	// The sizes of the read and write buffers of every websocket connection in bytes. (0 => the buffers of the HTTP server are reused)
+	ReadBufferSize  int
+	WriteBufferSize int
+	// Negotiate per-message compression with clients which support it.
+	EnableCompression bool
+	// The maximum duration of a websocket handshake. (0 => no limit)
+	HandshakeTimeout time.Duration
+}
+
+func (s *Server) newUpgrader() websocket.Upgrader {
+	config := s.config.Upgrader
+	checkOrigin := config.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = s.checkOrigin
+	}
+	return websocket.Upgrader{
+		CheckOrigin:       checkOrigin,
+		ReadBufferSize:    config.ReadBufferSize,
+		WriteBufferSize:   config.WriteBufferSize,
+		EnableCompression: config.EnableCompression,
+		HandshakeTimeout:  config.HandshakeTimeout,
+	}
+}
@@ -0,0 +1,96 @@
+package cg
+
+import (
+	"time"
+)
+
+// The maximum number of rejected commands kept in the audit trail of a game.
+const maxRejectedCommands = 100
+
+type RejectedCommand struct {
+	PlayerID string
+	Username string
+	Cmd      Command
+	Reason   string
+	Time     time.Time
+}
+
+// RejectedCommands returns the most recent commands rejected by the CommandValidator of the game.
+func (g *Game) RejectedCommands() []RejectedCommand {
+	g.rejectedCommandsLock.RLock()
+	defer g.rejectedCommandsLock.RUnlock()
+	rejected := make([]RejectedCommand, len(g.rejectedCommands))
+	copy(rejected, g.rejectedCommands)
+	return rejected
+}
+
+// Strikes returns the number of commands of the player which were rejected by the CommandValidator of the game.
+func (p *Player) Strikes() int {
+	p.game.rejectedCommandsLock.RLock()
+	defer p.game.rejectedCommandsLock.RUnlock()
+	return p.strikes
+}
+
+func (g *Game) validateCommand(player *Player, cmd Command) error {
+	if g.CommandValidator == nil {
+		return nil
+	}
+
+	err := g.CommandValidator(player, cmd)
+	if err == nil {
+		return nil
+	}
+
+	g.rejectedCommandsLock.Lock()
+	player.strikes++
+	strikes := player.strikes
+	if len(g.rejectedCommands) >= maxRejectedCommands {
+		g.rejectedCommands = g.rejectedCommands[1:]
+	}
+	g.rejectedCommands = append(g.rejectedCommands, RejectedCommand{
+		PlayerID: player.ID,
+		Username: player.username(),
+		Cmd:      cmd,
+		Reason:   err.Error(),
+		Time:     g.server.config.Clock.Now(),
+	})
+	g.rejectedCommandsLock.Unlock()
+
+	g.Log.WarningData(cmd, "Rejected '%s' command from player '%s' (%s) (strike %d): %s", cmd.Name, player.username(), player.ID, strikes, err)
+
+	banAfter := g.server.config.BanAfterStrikes
+	kickAfter := g.server.config.KickAfterStrikes
+	if banAfter > 0 && strikes >= banAfter {
+		g.ban(player)
+	} else if kickAfter > 0 && strikes >= kickAfter {
+		g.Log.Warning("Kicking player '%s' (%s) after %d strikes.", player.username(), player.ID, strikes)
+		g.leave(player)
+	}
+
+	return err
+}
+
+// ban prevents the address and the client secret of the player from joining the game again and removes the player.
+func (g *Game) ban(player *Player) {
+	g.bannedLock.Lock()
+	if address := player.getAddress(); address != "" {
+		g.bannedAddresses[address] = struct{}{}
+	}
+	if player.clientSecret != "" {
+		g.bannedClientSecrets[player.clientSecret] = struct{}{}
+	}
+	g.bannedLock.Unlock()
+	g.Log.Warning("Banning player '%s' (%s).", player.username(), player.ID)
+	g.leave(player)
+}
+
+// isBanned returns true if the address or the client secret hash belongs to a banned player.
+func (g *Game) isBanned(address, clientSecret string) bool {
+	g.bannedLock.RLock()
+	defer g.bannedLock.RUnlock()
+	if _, ok := g.bannedAddresses[address]; ok && address != "" {
+		return true
+	}
+	_, ok := g.bannedClientSecrets[clientSecret]
+	return ok && clientSecret != ""
+}
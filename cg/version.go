@@ -0,0 +1,56 @@
+package cg
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// The oldest CodeGame protocol version supported by this package.
+	MinClientCGVersion = "0.8"
+	// The newest CodeGame protocol version supported by this package.
+	MaxClientCGVersion = CGVersion
+)
+
+// IsCompatible returns true if a client speaking the CodeGame protocol version clientVersion can connect to the server.
+// Patch versions are ignored.
+func IsCompatible(clientVersion string) bool {
+	major, minor, _, err := parseVersion(strings.TrimPrefix(clientVersion, "v"))
+	if err != nil {
+		return false
+	}
+	minMajor, minMinor, _, _ := parseVersion(MinClientCGVersion)
+	maxMajor, maxMinor, _, _ := parseVersion(MaxClientCGVersion)
+	return compareVersions(major, minor, minMajor, minMinor) >= 0 && compareVersions(major, minor, maxMajor, maxMinor) <= 0
+}
+
+func compareVersions(major1, minor1, major2, minor2 int) int {
+	if major1 != major2 {
+		return major1 - major2
+	}
+	return minor1 - minor2
+}
+
+// checkClientVersion rejects the request with a structured error if the client sent an incompatible cg_version query parameter.
+// Clients which do not send their version are accepted.
+func checkClientVersion(w http.ResponseWriter, r *http.Request) bool {
+	version := r.URL.Query().Get("cg_version")
+	if version == "" || IsCompatible(version) {
+		return true
+	}
+
+	type response struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+		Min     string `json:"min_cg_version"`
+		Max     string `json:"max_cg_version"`
+	}
+	sendJSON(w, http.StatusBadRequest, response{
+		Error:   "incompatible_version",
+		Message: fmt.Sprintf("CodeGame version %s is not supported by this server", version),
+		Min:     MinClientCGVersion,
+		Max:     MaxClientCGVersion,
+	})
+	return false
+}
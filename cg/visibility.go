@@ -0,0 +1,28 @@
+package cg
+
+// Visibility controls which information about a game is exposed by the public API.
+// The zero value exposes everything.
+type Visibility struct {
+	// Hide the usernames and IDs of the players: /api/games/{gameId}/players and /api/games/{gameId}/players/{playerId} are rejected and the host is omitted.
+	HidePlayers bool
+	// Omit the config from /api/games/{gameId}.
+	HideConfig bool
+	// Omit the uptime, event, command and spectator counts from /api/games/{gameId}.
+	HideStats bool
+}
+
+// SetVisibility sets which information about the game is exposed by the public API,
+// e.g. to list games of privacy-sensitive deployments without leaking usernames.
+// The admin API is not affected.
+func (g *Game) SetVisibility(visibility Visibility) {
+	g.configLock.Lock()
+	g.visibility = visibility
+	g.configLock.Unlock()
+}
+
+// Visibility returns the visibility set with SetVisibility.
+func (g *Game) Visibility() Visibility {
+	g.configLock.RLock()
+	defer g.configLock.RUnlock()
+	return g.visibility
+}
@@ -0,0 +1,209 @@
+package cg
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// CommandVote is sent by players to cast or change their ballot in a running vote.
+	CommandVote CommandName = "cg_vote"
+
+	EventVoteStarted EventName = "cg_vote_started"
+	EventVoteEnded   EventName = "cg_vote_ended"
+)
+
+type VoteCommandData struct {
+	Vote   string `json:"vote"`
+	Option int    `json:"option"`
+}
+
+type VoteStartedEventData struct {
+	Vote     string   `json:"vote"`
+	Question string   `json:"question"`
+	Options  []string `json:"options"`
+	// The time at which the vote ends in unix milliseconds.
+	Deadline int64 `json:"deadline"`
+}
+
+type VoteEndedEventData struct {
+	Vote     string   `json:"vote"`
+	Question string   `json:"question"`
+	Options  []string `json:"options"`
+	// The number of ballots for each option.
+	Counts []int `json:"counts"`
+	// The index of the winning option (-1 => nobody voted).
+	Winner int `json:"winner"`
+}
+
+type Vote struct {
+	ID       string
+	Question string
+	Options  []string
+
+	game     *Game
+	eligible func(player *Player) bool
+	timer    Timer
+	done     chan struct{}
+
+	lock    sync.Mutex
+	ballots map[string]int
+	result  VoteEndedEventData
+	ended   bool
+}
+
+// StartVote asks all eligible players to choose one of the options within duration.
+// Players vote with the standard cg_vote command. If eligible is nil, all players may vote.
+// The vote ends when all eligible players have voted or the time is up.
+// Ties are broken randomly and the result is broadcast with the cg_vote_ended event.
+func (g *Game) StartVote(question string, options []string, eligible func(player *Player) bool, duration time.Duration) *Vote {
+	v := &Vote{
+		ID:       uuid.NewString(),
+		Question: question,
+		Options:  options,
+		game:     g,
+		eligible: eligible,
+		done:     make(chan struct{}),
+		ballots:  make(map[string]int),
+	}
+
+	g.votesLock.Lock()
+	g.votes[v.ID] = v
+	g.votesLock.Unlock()
+
+	clock := g.server.config.Clock
+	g.SendVoteStarted(v, clock.Now().Add(duration))
+
+	v.lock.Lock()
+	v.timer = clock.AfterFunc(duration, v.End)
+	v.lock.Unlock()
+
+	return v
+}
+
+// Wait blocks until the vote has ended and returns the index of the winning option (-1 => nobody voted).
+func (v *Vote) Wait() int {
+	<-v.done
+	return v.result.Winner
+}
+
+// Done returns a channel which is closed when the vote has ended.
+func (v *Vote) Done() <-chan struct{} {
+	return v.done
+}
+
+// Counts returns the number of ballots for each option.
+func (v *Vote) Counts() []int {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	return v.counts()
+}
+
+// End ends the vote immediately and broadcasts the result.
+func (v *Vote) End() {
+	v.lock.Lock()
+	if v.ended {
+		v.lock.Unlock()
+		return
+	}
+	v.ended = true
+	if v.timer != nil {
+		v.timer.Stop()
+	}
+
+	counts := v.counts()
+	winner := -1
+	candidates := make([]int, 0, len(counts))
+	for i, c := range counts {
+		if c == 0 {
+			continue
+		}
+		if winner < 0 || c > counts[winner] {
+			winner = i
+			candidates = candidates[:0]
+		}
+		if c == counts[winner] {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) > 1 {
+		winner = candidates[rand.Intn(len(candidates))]
+	}
+
+	v.result = VoteEndedEventData{
+		Vote:     v.ID,
+		Question: v.Question,
+		Options:  v.Options,
+		Counts:   counts,
+		Winner:   winner,
+	}
+	v.lock.Unlock()
+
+	v.game.votesLock.Lock()
+	delete(v.game.votes, v.ID)
+	v.game.votesLock.Unlock()
+
+	v.game.SendVoteEnded(v.result)
+	close(v.done)
+}
+
+func (v *Vote) counts() []int {
+	counts := make([]int, len(v.Options))
+	for _, option := range v.ballots {
+		counts[option]++
+	}
+	return counts
+}
+
+func (v *Vote) cast(player *Player, option int) error {
+	if v.eligible != nil && !v.eligible(player) {
+		return errors.New("not eligible to vote")
+	}
+	if option < 0 || option >= len(v.Options) {
+		return errors.New("invalid vote option")
+	}
+
+	v.lock.Lock()
+	if v.ended {
+		v.lock.Unlock()
+		return errors.New("vote has ended")
+	}
+	v.ballots[player.ID] = option
+	ballots := len(v.ballots)
+	v.lock.Unlock()
+
+	eligible := 0
+	v.game.playersLock.RLock()
+	for _, p := range v.game.players {
+		if v.eligible == nil || v.eligible(p) {
+			eligible++
+		}
+	}
+	v.game.playersLock.RUnlock()
+
+	if ballots >= eligible {
+		v.End()
+	}
+	return nil
+}
+
+func (g *Game) handleVote(player *Player, cmd Command) error {
+	var data VoteCommandData
+	err := cmd.UnmarshalData(&data)
+	if err != nil {
+		return err
+	}
+
+	g.votesLock.RLock()
+	vote, ok := g.votes[data.Vote]
+	g.votesLock.RUnlock()
+	if !ok {
+		return errors.New("vote not found")
+	}
+
+	return vote.cast(player, data.Option)
+}
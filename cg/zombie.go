@@ -0,0 +1,109 @@
+package cg
+
+// markQueued starts the wait of the queued commands if the queue is empty before a command is added.
+func (g *Game) markQueued() {
+	g.consumedLock.Lock()
+	if len(g.cmdChan) == 0 {
+		g.waitingSince = g.server.config.Clock.Now()
+	}
+	g.consumedLock.Unlock()
+}
+
+// markConsumed restarts the wait of the remaining queued commands because the game loop made progress.
+func (g *Game) markConsumed() {
+	g.consumedLock.Lock()
+	g.waitingSince = g.server.config.Clock.Now()
+	g.stalled = false
+	g.consumedLock.Unlock()
+}
+
+// markStalled returns true if the game was not already known to be stalled since it last took a command.
+func (g *Game) markStalled() bool {
+	g.consumedLock.Lock()
+	defer g.consumedLock.Unlock()
+	if g.stalled {
+		return false
+	}
+	g.stalled = true
+	return true
+}
+
+// isZombie returns true if the queued commands of the running game have been waiting for the game loop for longer than ZombieTimeout.
+// Closed games are not zombies because Close removes them from the server itself.
+func (g *Game) isZombie() bool {
+	if !g.Running() {
+		return false
+	}
+
+	if len(g.cmdChan) == 0 {
+		return false
+	}
+
+	g.consumedLock.Lock()
+	waitingSince := g.waitingSince
+	g.consumedLock.Unlock()
+
+	return g.server.config.Clock.Now().Sub(waitingSince) >= g.server.config.ZombieTimeout
+}
+
+func (s *Server) watchZombies() {
+	ticker := s.config.Clock.NewTicker(s.config.ZombieTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C():
+			s.reapZombies()
+		case <-s.shutdown:
+			return
+		}
+	}
+}
+
+func (s *Server) reapZombies() {
+	s.gamesLock.RLock()
+	zombies := make([]*Game, 0)
+	for _, g := range s.games {
+		if g.isZombie() {
+			zombies = append(zombies, g)
+		}
+	}
+	s.gamesLock.RUnlock()
+
+	s.zombieLock.Lock()
+	s.zombieGames = len(zombies)
+	s.zombieLock.Unlock()
+
+	for _, g := range zombies {
+		// the game may have been closed by another goroutine in the meantime
+		if !g.Running() {
+			continue
+		}
+
+		g.playersLock.RLock()
+		playerCount := len(g.players)
+		g.playersLock.RUnlock()
+		queued := len(g.cmdChan)
+		s.log.Warning("Game %s with %d players has not processed its %d queued commands for %s.", g.ID, playerCount, queued, s.config.ZombieTimeout)
+
+		if g.markStalled() {
+			g.Log.Warning("The game loop has not processed its %d queued commands for %s.", queued, s.config.ZombieTimeout)
+			if g.OnStalled != nil {
+				g.OnStalled(queued)
+			}
+		}
+
+		if s.config.CloseZombieGames {
+			s.log.Warning("Closing zombie game %s.", g.ID)
+			g.CloseWithReason("game loop stalled")
+			s.zombieLock.Lock()
+			s.reapedGamesTotal++
+			s.zombieLock.Unlock()
+		}
+	}
+}
+
+func (s *Server) zombieStats() (int, int) {
+	s.zombieLock.Lock()
+	defer s.zombieLock.Unlock()
+	return s.zombieGames, s.reapedGamesTotal
+}